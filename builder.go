@@ -2,22 +2,134 @@ package expr
 
 import (
 	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strconv"
 	"strings"
+	"time"
+
+	"github.com/altipla-consulting/expr/parse"
 )
 
-type BuildField func() string
+// BuildField construye una parte de la consulta como nodos del árbol que
+// devuelve el parser.
+type BuildField func() parse.Node
 
+// Builder construye la consulta y la devuelve como texto.
 func Builder(fields ...BuildField) string {
-	return And(fields...)()
+	return parse.Format(Build(fields...))
+}
+
+// Build construye la consulta como un árbol que se puede inspeccionar o
+// comprobar con Filters.Validate directamente.
+func Build(fields ...BuildField) *parse.AndNode {
+	return And(fields...)().(*parse.AndNode)
 }
 
 func And(fields ...BuildField) BuildField {
-	return func() string {
-		q := make([]string, len(fields))
-		for i, field := range fields {
-			q[i] = field()
+	return func() parse.Node {
+		root := &parse.AndNode{
+			NodeType: parse.NodeAnd,
+		}
+		for _, field := range fields {
+			// Las secuencias dentro de otras se unen en una sola.
+			node := field()
+			if seq, ok := node.(*parse.AndNode); ok {
+				root.Nodes = append(root.Nodes, seq.Nodes...)
+			} else {
+				root.Nodes = append(root.Nodes, node)
+			}
+		}
+		return root
+	}
+}
+
+// Or une las condiciones con OR. Como OR tiene más prioridad que AND en la
+// gramática las secuencias de condiciones se meten en un grupo.
+func Or(fields ...BuildField) BuildField {
+	return func() parse.Node {
+		or := &parse.OrNode{
+			NodeType: parse.NodeOr,
+		}
+		for _, field := range fields {
+			switch node := field().(type) {
+			case *parse.OrNode:
+				or.Nodes = append(or.Nodes, node.Nodes...)
+			default:
+				or.Nodes = append(or.Nodes, buildTerm(node))
+			}
+		}
+		switch len(or.Nodes) {
+		case 0:
+			panic("empty OR in query builder")
+		case 1:
+			return or.Nodes[0]
+		}
+		return or
+	}
+}
+
+// Group escribe las condiciones entre paréntesis.
+func Group(fields ...BuildField) BuildField {
+	return func() parse.Node {
+		seq := And(fields...)().(*parse.AndNode)
+		if len(seq.Nodes) == 0 {
+			panic("empty group in query builder")
+		}
+		return &parse.GroupNode{
+			NodeType: parse.NodeGroup,
+			Expr:     seq,
+		}
+	}
+}
+
+// Not niega la condición, que puede ser una comparación o un grupo de ellas.
+func Not(field BuildField) BuildField {
+	return func() parse.Node {
+		switch node := buildTerm(field()).(type) {
+		case *parse.ExprNode:
+			negated := *node
+			negated.Negative = !node.Negative
+			return &negated
+
+		case *parse.GroupNode:
+			negated := *node
+			negated.Negative = !node.Negative
+			return &negated
+		}
+
+		panic("should not reach here")
+	}
+}
+
+// buildTerm convierte el nodo en una comparación o un grupo, que son los
+// términos que admiten OR y la negación.
+func buildTerm(node parse.Node) parse.Node {
+	switch node := node.(type) {
+	case *parse.ExprNode, *parse.GroupNode:
+		return node
+
+	case *parse.AndNode:
+		switch len(node.Nodes) {
+		case 0:
+			panic("empty group in query builder")
+		case 1:
+			return buildTerm(node.Nodes[0])
+		}
+		return &parse.GroupNode{
+			NodeType: parse.NodeGroup,
+			Expr:     node,
+		}
+
+	default:
+		return &parse.GroupNode{
+			NodeType: parse.NodeGroup,
+			Expr: &parse.AndNode{
+				NodeType: parse.NodeAnd,
+				Nodes:    []parse.Node{node},
+			},
 		}
-		return strings.Join(q, " ")
 	}
 }
 
@@ -27,22 +139,137 @@ type enumValue interface {
 }
 
 func Eq(name string, value interface{}) BuildField {
-	return func() string {
-		switch v := value.(type) {
-		case enumValue:
-			return fmt.Sprintf("%v=%v", name, v.String())
+	return buildExpr(name, parse.OpEqual, value)
+}
+
+func Ne(name string, value interface{}) BuildField {
+	return buildExpr(name, parse.OpNotEqual, value)
+}
+
+func Gt(name string, value interface{}) BuildField {
+	return buildExpr(name, parse.OpGreaterThan, value)
+}
+
+func Gte(name string, value interface{}) BuildField {
+	return buildExpr(name, parse.OpGreaterOrEqualThan, value)
+}
 
-		case int64, int32, int, bool:
-			return fmt.Sprintf("%v=%v", name, value)
+func Lt(name string, value interface{}) BuildField {
+	return buildExpr(name, parse.OpLessThan, value)
+}
 
-		default:
-			panic(fmt.Sprintf("unsupported type in query builder for field %s: %T", name, value))
+func Lte(name string, value interface{}) BuildField {
+	return buildExpr(name, parse.OpLessOrEqualThan, value)
+}
+
+func Contains(name string, value string) BuildField {
+	return buildExpr(name, parse.OpContains, value)
+}
+
+func In(name string, values ...interface{}) BuildField {
+	return func() parse.Node {
+		if len(values) == 0 {
+			panic(fmt.Sprintf("empty list of values in query builder for field %s", name))
+		}
+		list := &parse.ListNode{
+			NodeType: parse.NodeList,
 		}
+		for _, value := range values {
+			list.Vals = append(list.Vals, buildValue(name, value))
+		}
+		return newBuildExpr(name, parse.OpIn, list)
 	}
 }
 
 func Exists(name string) BuildField {
-	return func() string {
-		return name + ":*"
+	return func() parse.Node {
+		return newBuildExpr(name, parse.OpExists, nil)
+	}
+}
+
+var fieldNameRe = regexp.MustCompile(`^[a-zA-Z0-9.][a-zA-Z0-9.-]*$`)
+
+func buildExpr(name string, op parse.Operator, value interface{}) BuildField {
+	return func() parse.Node {
+		return newBuildExpr(name, op, buildValue(name, value))
 	}
 }
+
+func newBuildExpr(name string, op parse.Operator, value parse.Node) *parse.ExprNode {
+	if !fieldNameRe.MatchString(name) || parse.IsKeyword(name) {
+		panic(fmt.Sprintf("invalid field name in query builder: %q", name))
+	}
+	return &parse.ExprNode{
+		NodeType: parse.NodeExpr,
+		Field: &parse.FieldNode{
+			NodeType: parse.NodeField,
+			Name:     name,
+		},
+		Op: &parse.OperatorNode{
+			NodeType: parse.NodeOperator,
+			Val:      op,
+		},
+		Val: value,
+	}
+}
+
+var decimalRe = regexp.MustCompile(`^[+-]?[0-9]+(\.[0-9]+)?$`)
+
+// buildValue convierte el valor en el nodo que generaría el parser. Los strings
+// van siempre entre comillas para que no se confundan con constantes y las
+// fechas se escriben en RFC 3339.
+func buildValue(name string, value interface{}) parse.Node {
+	switch v := value.(type) {
+	case enumValue:
+		return &parse.ConstantNode{NodeType: parse.NodeConstant, Name: v.String()}
+
+	case bool:
+		return &parse.ConstantNode{NodeType: parse.NodeConstant, Name: strconv.FormatBool(v)}
+
+	case string:
+		return &parse.StringNode{NodeType: parse.NodeString, Quoted: strconv.Quote(v)}
+
+	case time.Time:
+		return &parse.StringNode{NodeType: parse.NodeString, Quoted: strconv.Quote(v.Format(time.RFC3339Nano))}
+
+	case Decimal:
+		if !decimalRe.MatchString(string(v)) {
+			panic(fmt.Sprintf("invalid decimal in query builder for field %s: %s", name, v))
+		}
+		if !strings.Contains(string(v), ".") {
+			n, err := strconv.ParseInt(string(v), 10, 64)
+			if err == nil {
+				return &parse.NumberNode{NodeType: parse.NodeNumber, Val: n}
+			}
+		}
+		f, _ := strconv.ParseFloat(string(v), 64)
+		return &parse.FloatNode{NodeType: parse.NodeFloat, Val: f, Text: string(v)}
+	}
+
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &parse.NumberNode{NodeType: parse.NodeNumber, Val: rv.Int()}
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if rv.Uint() > math.MaxInt64 {
+			panic(fmt.Sprintf("number out of range in query builder for field %s: %v", name, rv.Uint()))
+		}
+		return &parse.NumberNode{NodeType: parse.NodeNumber, Val: int64(rv.Uint())}
+
+	case reflect.Float32, reflect.Float64:
+		f := rv.Float()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			panic(fmt.Sprintf("invalid number in query builder for field %s: %v", name, f))
+		}
+
+		// Los números sin decimales ni exponente se leerían como enteros.
+		text := strconv.FormatFloat(f, 'g', -1, 64)
+		if !strings.ContainsAny(text, ".e") {
+			text += ".0"
+		}
+		return &parse.FloatNode{NodeType: parse.NodeFloat, Val: f, Text: text}
+	}
+
+	panic(fmt.Sprintf("unsupported type in query builder for field %s: %T", name, value))
+}
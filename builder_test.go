@@ -0,0 +1,212 @@
+package expr
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/altipla-consulting/expr/parse"
+	pb "github.com/altipla-consulting/expr/testdata/foo"
+)
+
+func TestBuilder(t *testing.T) {
+	ts := time.Date(2019, time.March, 2, 14, 15, 16, 500, time.UTC)
+
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		{Builder(Eq("id", int64(3)), Eq("enum", pb.FooEnum_FOOENUM_FIRST)), `id=3 enum=FOOENUM_FIRST`},
+		{Builder(Eq("bool", true), Exists("ts")), `bool=true ts:*`},
+		{Builder(Ne("id", 3), Gt("ts", ts), Lte("rating", 4.5)), `id!=3 ts>"2019-03-02T14:15:16.0000005Z" rating<=4.5`},
+		{Builder(Gte("n", uint32(2)), Lt("n", int8(-2))), `n>=2 n<-2`},
+		{Builder(Eq("rating", 1e21)), `rating=1e+21`},
+		{Builder(Eq("rating", float32(3))), `rating=3.0`},
+		{Builder(Eq("price", Decimal("10.50"))), `price=10.50`},
+		{Builder(Contains("name", `say "hi" \o/`)), `name:"say \"hi\" \\o/"`},
+		{Builder(Eq("name", "foo bar")), `name="foo bar"`},
+		{Builder(Eq("name", "OR")), `name="OR"`},
+		{Builder(In("id", 1, 2, 3)), `id IN (1, 2, 3)`},
+		{Builder(Not(Eq("id", 3))), `-id=3`},
+		{Builder(Not(Eq("name", "a b"))), `-name="a b"`},
+		{Builder(Not(Group(Eq("id", 3), Eq("id", 4)))), `NOT (id=3 id=4)`},
+		{Builder(Not(Not(Eq("id", 3)))), `id=3`},
+		{Builder(Not(And(Eq("id", 3), Eq("id", 4)))), `NOT (id=3 id=4)`},
+		{Builder(Or(Eq("id", 3), Eq("name", "a b"))), `id=3 OR name="a b"`},
+		{Builder(Or(And(Eq("id", 3), Eq("id", 4)), Eq("id", 5)), Exists("ts")), `(id=3 id=4) OR id=5 ts:*`},
+		{Builder(Group(Eq("id", 3), Or(Eq("id", 4), Eq("id", 5)))), `(id=3 id=4 OR id=5)`},
+	}
+	for _, test := range tests {
+		require.Equal(t, test.expected, test.query)
+
+		_, err := parse.Parse(test.query)
+		require.NoError(t, err, test.query)
+	}
+}
+
+func TestBuilderRoundTrip(t *testing.T) {
+	filters := Filters{
+		IDParam("id"),
+		StringParam("name"),
+		TimestampParam("ts"),
+	}
+	ts := time.Date(2019, time.March, 2, 14, 15, 16, 500, time.FixedZone("CET", 3600))
+
+	matcher, err := filters.Matcher(Builder(
+		Or(Eq("id", 3), And(Eq("id", 4), Contains("name", `"quoted" value`))),
+		Gt("ts", ts),
+	))
+	require.NoError(t, err)
+
+	require.True(t, matcher(map[string]interface{}{"id": 3, "ts": ts.Add(time.Nanosecond)}))
+	require.True(t, matcher(map[string]interface{}{"id": 4, "name": `a "quoted" value`, "ts": ts.Add(time.Second)}))
+	require.False(t, matcher(map[string]interface{}{"id": 4, "name": "foo", "ts": ts.Add(time.Second)}))
+	require.False(t, matcher(map[string]interface{}{"id": 3, "ts": ts}))
+}
+
+func TestBuilderUnsupported(t *testing.T) {
+	require.Panics(t, func() {
+		Builder(Eq("foo", []string{"bar"}))
+	})
+	require.Panics(t, func() {
+		Builder(Eq("foo", Decimal("1e3")))
+	})
+
+	empty := []BuildField{
+		In("id"),
+		Group(),
+		Group(And()),
+		Not(And()),
+		Or(Eq("id", 3), And()),
+		Or(),
+	}
+	for _, field := range empty {
+		require.Panics(t, func() {
+			Builder(field)
+		})
+	}
+}
+
+func TestBuildTree(t *testing.T) {
+	fields := []BuildField{
+		Or(And(Eq("id", 3), Contains("name", "foo")), Not(Eq("id", 5))),
+		In("id", 1, 2),
+		Not(Group(Exists("ts"), Gte("rating", 2.5))),
+		Lt("price", Decimal("10.50")),
+		Eq("enum", pb.FooEnum_FOOENUM_FIRST),
+	}
+
+	root := Build(fields...)
+	parsed, err := parse.Parse(Builder(fields...))
+	require.NoError(t, err)
+	require.Equal(t, parsed, root)
+}
+
+func TestBuildValidate(t *testing.T) {
+	filters := Filters{
+		IDParam("id"),
+		StringParam("name"),
+	}
+
+	root := Build(Or(Eq("id", 3), Contains("name", `it's "quoted"`)))
+	require.NoError(t, filters.Validate(root))
+
+	sql, vals, err := filters.ToSQLNode(root)
+	require.NoError(t, err)
+	require.Equal(t, "((id = ?) OR (name LIKE ?))", sql)
+	require.Equal(t, []interface{}{int64(3), `%it's "quoted"%`}, vals)
+
+	require.Error(t, filters.Validate(Build(Eq("id", "foo"))))
+	require.Error(t, filters.Validate(Build(Eq("unknown", 3))))
+	require.Error(t, filters.Validate(Build(Gt("id", 3))))
+	require.Error(t, filters.Validate(nil))
+}
+
+func TestValidateMalformedTree(t *testing.T) {
+	filters := Filters{
+		IDParam("id"),
+	}
+	expr := func(op parse.Operator, val parse.Node) *parse.ExprNode {
+		return &parse.ExprNode{
+			NodeType: parse.NodeExpr,
+			Field:    &parse.FieldNode{NodeType: parse.NodeField, Name: "id"},
+			Op:       &parse.OperatorNode{NodeType: parse.NodeOperator, Val: op},
+			Val:      val,
+		}
+	}
+	number := &parse.NumberNode{NodeType: parse.NodeNumber, Val: 3}
+
+	tests := []parse.Node{
+		expr(parse.OpEqual, nil),
+		expr(parse.OpIn, number),
+		expr(parse.Operator("~"), number),
+		&parse.ExprNode{NodeType: parse.NodeExpr},
+		&parse.AndNode{NodeType: parse.NodeAnd, Nodes: []parse.Node{expr(parse.OpEqual, number)}},
+		&parse.OrNode{NodeType: parse.NodeOr, Nodes: []parse.Node{expr(parse.OpEqual, number)}},
+		&parse.GroupNode{NodeType: parse.NodeGroup},
+		&parse.GroupNode{NodeType: parse.NodeGroup, Expr: &parse.AndNode{NodeType: parse.NodeAnd}},
+		&parse.AndNode{NodeType: parse.NodeAnd},
+		expr(parse.OpIn, &parse.ListNode{NodeType: parse.NodeList}),
+		expr(parse.OpIn, &parse.ListNode{NodeType: parse.NodeList, Vals: []parse.Node{number, nil}}),
+		expr(parse.OpIn, &parse.ListNode{NodeType: parse.NodeList, Vals: []parse.Node{&parse.ListNode{NodeType: parse.NodeList, Vals: []parse.Node{number}}}}),
+		expr(parse.OpIn, &parse.ListNode{NodeType: parse.NodeList, Vals: []parse.Node{expr(parse.OpEqual, number)}}),
+		expr(parse.OpIn, &parse.ListNode{NodeType: parse.NodeList, Vals: []parse.Node{&parse.StringNode{NodeType: parse.NodeString, Quoted: `"a`}}}),
+		expr(parse.OpGreaterThan, &parse.StringNode{NodeType: parse.NodeString, Quoted: "foo"}),
+		expr(parse.OpGreaterThan, &parse.StringNode{NodeType: parse.NodeString, Quoted: "`foo`"}),
+		expr(parse.OpEqual, &parse.FloatNode{NodeType: parse.NodeFloat, Text: "foo"}),
+		expr(parse.OpEqual, &parse.FloatNode{NodeType: parse.NodeFloat, Text: "Inf"}),
+		expr(parse.OpEqual, &parse.FloatNode{NodeType: parse.NodeFloat, Text: "1e999"}),
+		expr(parse.OpEqual, &parse.ConstantNode{NodeType: parse.NodeConstant, Name: "a b"}),
+		expr(parse.OpEqual, &parse.FieldNode{NodeType: parse.NodeField, Name: "id"}),
+		number,
+	}
+	for _, test := range tests {
+		root := &parse.AndNode{
+			NodeType: parse.NodeAnd,
+			Nodes:    []parse.Node{test},
+		}
+		require.Error(t, filters.Validate(root), "%#v", test)
+	}
+
+	ts := Filters{
+		TimestampParam("ts"),
+	}
+	root := &parse.AndNode{
+		NodeType: parse.NodeAnd,
+		Nodes: []parse.Node{
+			&parse.ExprNode{
+				NodeType: parse.NodeExpr,
+				Field:    &parse.FieldNode{NodeType: parse.NodeField, Name: "ts"},
+				Op:       &parse.OperatorNode{NodeType: parse.NodeOperator, Val: parse.OpGreaterThan},
+				Val:      &parse.StringNode{NodeType: parse.NodeString, Quoted: "2006-01-02"},
+			},
+		},
+	}
+	require.NotPanics(t, func() {
+		err := ts.Validate(root)
+		require.Error(t, err)
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
+
+func TestBuilderInvalidFieldName(t *testing.T) {
+	require.Panics(t, func() {
+		Builder(Eq("id=3 OR id", 4))
+	})
+	require.Panics(t, func() {
+		Builder(Eq("-id", 4))
+	})
+
+	for _, keyword := range []string{"AND", "OR", "NOT", "IN"} {
+		require.Panics(t, func() {
+			Builder(Eq("a", 1), In(keyword, 1))
+		}, keyword)
+	}
+
+	query := Builder(Eq("ANDROID", 1), In("not", 1), Exists("INDEX"))
+	_, err := parse.Parse(query)
+	require.NoError(t, err, query)
+}
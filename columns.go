@@ -0,0 +1,79 @@
+package expr
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// column es la expresión SQL a la que se traduce un filtro. Puede ser una
+// columna normal, opcionalmente con la tabla delante, un camino dentro de una
+// columna JSON o una expresión SQL escrita a mano.
+type column struct {
+	table, name string
+	jsonPath    []string
+	expr        string
+	typ         columnType
+}
+
+// columnType es el tipo de los valores que compara el filtro. Los motores que
+// extraen los valores de JSON como texto lo usan para convertirlos antes.
+type columnType int
+
+const (
+	columnText columnType = iota
+	columnInteger
+	columnFloat
+	columnDecimal
+	columnBool
+	columnTimestamp
+)
+
+func parseColumn(name string) column {
+	var c column
+	if i := strings.LastIndex(name, "."); i != -1 {
+		c.table, name = name[:i], name[i+1:]
+	}
+	c.name = name
+	return c
+}
+
+func (c column) isZero() bool {
+	return c.name == "" && c.expr == ""
+}
+
+// mustIdentifier comprueba que el nombre se puede escribir directamente en la
+// consulta SQL sin riesgo de inyecciones. Los nombres vienen siempre del código
+// y no del usuario, así que un error es un fallo de programación.
+func mustIdentifier(name string) string {
+	for _, part := range strings.Split(name, ".") {
+		if part == "" {
+			panic(fmt.Sprintf("invalid SQL identifier in filter column: %q", name))
+		}
+		for _, r := range part {
+			if r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+				panic(fmt.Sprintf("invalid SQL identifier in filter column: %q", name))
+			}
+		}
+	}
+	return name
+}
+
+// sqlizeName convierte el nombre del filtro en camelCase al nombre de la
+// columna en snake_case. Los acrónimos se mantienen juntos, de forma que
+// userID se convierte en user_id y HTTPStatus en http_status.
+func sqlizeName(s string) string {
+	runes := []rune(s)
+	var result []rune
+	for i, r := range runes {
+		if unicode.IsUpper(r) && i > 0 && runes[i-1] != '.' {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if !unicode.IsUpper(prev) || nextLower {
+				result = append(result, '_')
+			}
+		}
+		result = append(result, unicode.ToLower(r))
+	}
+	return string(result)
+}
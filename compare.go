@@ -0,0 +1,163 @@
+package expr
+
+import (
+	"math/big"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// normalizeValue prepara el valor del dato para compararlo con el de la
+// consulta. Sigue los punteros, convierte las enumeraciones en su nombre como
+// se escriben en la consulta y quita los tipos con nombre de strings y
+// booleanos. Los punteros nulos se convierten en nil.
+func normalizeValue(v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	v = rv.Interface()
+
+	if enumv, ok := v.(enumValue); ok {
+		return enumv.String()
+	}
+
+	switch v.(type) {
+	case string, bool, Decimal:
+		return v
+	}
+	switch rv.Kind() {
+	case reflect.String:
+		return rv.String()
+	case reflect.Bool:
+		return rv.Bool()
+	}
+
+	return v
+}
+
+// compareValues ordena el valor del dato respecto al de la consulta. Los
+// valores de la consulta son siempre los que devuelve el eval de cada filtro,
+// mientras que los del dato pueden ser de cualquier tipo Go compatible. Devuelve
+// false si los tipos no se pueden comparar entre ellos.
+func compareValues(want, got interface{}) (int, bool) {
+	switch want := want.(type) {
+	case int64:
+		if n, ok := toInt64(got); ok {
+			return compareInt64(n, want), true
+		}
+		if n, ok := toFloat64(got); ok {
+			return compareFloat64(n, float64(want)), true
+		}
+
+	case float64:
+		if n, ok := toFloat64(got); ok {
+			return compareFloat64(n, want), true
+		}
+
+	case Decimal:
+		r := toRat(got)
+		if r == nil {
+			return 0, false
+		}
+		return r.Cmp(want.rat()), true
+
+	case time.Time:
+		if t, ok := got.(time.Time); ok {
+			switch {
+			case t.Before(want):
+				return -1, true
+			case t.After(want):
+				return 1, true
+			}
+			return 0, true
+		}
+
+	case string:
+		if s, ok := got.(string); ok {
+			return strings.Compare(s, want), true
+		}
+
+	case bool:
+		if b, ok := got.(bool); ok {
+			switch {
+			case b == want:
+				return 0, true
+			case want:
+				return -1, true
+			}
+			return 1, true
+		}
+	}
+
+	return 0, false
+}
+
+func compareInt64(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func compareFloat64(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func toInt64(v interface{}) (int64, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if u := rv.Uint(); u <= 1<<63-1 {
+			return int64(u), true
+		}
+	}
+	return 0, false
+}
+
+func toFloat64(v interface{}) (float64, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	}
+	return 0, false
+}
+
+func toRat(v interface{}) *big.Rat {
+	switch v := v.(type) {
+	case Decimal:
+		return v.rat()
+	case string:
+		return Decimal(v).rat()
+	}
+	if n, ok := toInt64(v); ok {
+		return new(big.Rat).SetInt64(n)
+	}
+	if n, ok := toFloat64(v); ok {
+		return new(big.Rat).SetFloat64(n)
+	}
+	return nil
+}
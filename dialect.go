@@ -0,0 +1,279 @@
+package expr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/altipla-consulting/expr/parse"
+)
+
+// Dialect adapta el SQL generado por los filtros a cada motor de base de datos.
+// Es una interfaz cerrada: sus métodos no se exportan porque dependen de
+// detalles internos de los filtros, así que solo se pueden usar los dialectos
+// de este paquete (MySQL, PostgreSQL y SQLite).
+type Dialect interface {
+	// placeholder devuelve el marcador del valor n, empezando en 1.
+	placeholder(n int) string
+
+	// column escribe la columna del filtro con el formato del motor.
+	column(c column) string
+
+	// compare escribe la comparación de la columna con el valor del marcador.
+	compare(column string, op parse.Operator, negative bool, placeholder string) string
+
+	// contains escribe la búsqueda de texto con un patrón LIKE ya escapado.
+	contains(column string, negative bool, placeholder string) string
+
+	// value convierte el valor de la consulta al tipo que espera el driver.
+	value(val interface{}) interface{}
+
+	// rowValues indica si el motor sabe comparar filas como (a, b) > (?, ?).
+	rowValues() bool
+}
+
+var (
+	MySQL      Dialect = mysqlDialect{}
+	PostgreSQL Dialect = postgresDialect{}
+	SQLite     Dialect = sqliteDialect{}
+)
+
+// SQLiteTimeFormat es el formato con el que se envían las fechas a SQLite. Se
+// guardan como texto en UTC y con un ancho fijo para que la comparación de
+// cadenas respete el orden cronológico, así que las columnas tienen que usar
+// el mismo formato.
+const SQLiteTimeFormat = "2006-01-02 15:04:05.000000000"
+
+type SQLOption func(opts *sqlOptions)
+
+type sqlOptions struct {
+	dialect          Dialect
+	placeholderStart int
+	keyset           *keyset
+}
+
+func WithDialect(dialect Dialect) SQLOption {
+	return func(opts *sqlOptions) {
+		opts.dialect = dialect
+	}
+}
+
+// WithPlaceholderStart numera los marcadores a partir de n para poder añadir
+// la condición a una consulta que ya tiene sus propios valores antes. Solo
+// afecta a los dialectos con marcadores numerados como PostgreSQL.
+func WithPlaceholderStart(n int) SQLOption {
+	return func(opts *sqlOptions) {
+		opts.placeholderStart = n
+	}
+}
+
+type mysqlDialect struct{}
+
+func (mysqlDialect) placeholder(n int) string {
+	return "?"
+}
+
+func (mysqlDialect) column(c column) string {
+	if c.expr != "" {
+		return c.expr
+	}
+
+	name := c.name
+	if c.table != "" {
+		name = c.table + "." + name
+	}
+	if len(c.jsonPath) > 0 {
+		return fmt.Sprintf("JSON_UNQUOTE(JSON_EXTRACT(%s, '$.%s'))", name, strings.Join(c.jsonPath, "."))
+	}
+	return name
+}
+
+// compare usa el operador <=> en las negaciones de la igualdad para que las
+// filas con NULL se comporten igual que en memoria y no desaparezcan.
+func (mysqlDialect) compare(column string, op parse.Operator, negative bool, placeholder string) string {
+	switch {
+	case op == parse.OpEqual && negative, op == parse.OpNotEqual && !negative:
+		return fmt.Sprintf("NOT (%s <=> %s)", column, placeholder)
+	case op == parse.OpNotEqual && negative:
+		return fmt.Sprintf("%s <=> %s", column, placeholder)
+	case negative:
+		return fmt.Sprintf("NOT %s %s %s", column, op, placeholder)
+	}
+	return fmt.Sprintf("%s %s %s", column, op, placeholder)
+}
+
+func (mysqlDialect) contains(column string, negative bool, placeholder string) string {
+	var not string
+	if negative {
+		not = "NOT "
+	}
+	return fmt.Sprintf("%s%s LIKE %s", not, column, placeholder)
+}
+
+func (mysqlDialect) value(val interface{}) interface{} {
+	return val
+}
+
+func (mysqlDialect) rowValues() bool {
+	return true
+}
+
+type postgresDialect struct{}
+
+func (postgresDialect) placeholder(n int) string {
+	return "$" + strconv.Itoa(n)
+}
+
+func (postgresDialect) column(c column) string {
+	if c.expr != "" {
+		return c.expr
+	}
+
+	var parts []string
+	if c.table != "" {
+		parts = strings.Split(c.table, ".")
+	}
+	parts = append(parts, c.name)
+	for i, part := range parts {
+		parts[i] = quoteIdent(part)
+	}
+	name := strings.Join(parts, ".")
+
+	if len(c.jsonPath) > 0 {
+		last := len(c.jsonPath) - 1
+		for _, key := range c.jsonPath[:last] {
+			name += "->'" + key + "'"
+		}
+		name += "->>'" + c.jsonPath[last] + "'"
+		if cast := postgresCasts[c.typ]; cast != "" {
+			name = "(" + name + ")::" + cast
+		}
+	}
+	return name
+}
+
+// postgresCasts convierte los valores que ->> extrae como texto al tipo del
+// filtro para que no se comparen como cadenas.
+var postgresCasts = map[columnType]string{
+	columnInteger:   "bigint",
+	columnFloat:     "double precision",
+	columnDecimal:   "numeric",
+	columnBool:      "boolean",
+	columnTimestamp: "timestamptz",
+}
+
+// compare usa IS DISTINCT FROM en las negaciones de la igualdad para que las
+// filas con NULL se comporten igual que en memoria y no desaparezcan.
+func (postgresDialect) compare(column string, op parse.Operator, negative bool, placeholder string) string {
+	switch {
+	case op == parse.OpEqual && negative, op == parse.OpNotEqual && !negative:
+		return fmt.Sprintf("%s IS DISTINCT FROM %s", column, placeholder)
+	case op == parse.OpNotEqual && negative:
+		return fmt.Sprintf("%s IS NOT DISTINCT FROM %s", column, placeholder)
+	case negative:
+		return fmt.Sprintf("NOT %s %s %s", column, op, placeholder)
+	}
+	return fmt.Sprintf("%s %s %s", column, op, placeholder)
+}
+
+func (postgresDialect) contains(column string, negative bool, placeholder string) string {
+	var not string
+	if negative {
+		not = "NOT "
+	}
+	return fmt.Sprintf("%s%s ILIKE %s", not, column, placeholder)
+}
+
+func (postgresDialect) value(val interface{}) interface{} {
+	return val
+}
+
+func (postgresDialect) rowValues() bool {
+	return true
+}
+
+type sqliteDialect struct{}
+
+func (sqliteDialect) placeholder(n int) string {
+	return "?"
+}
+
+func (sqliteDialect) column(c column) string {
+	if c.expr != "" {
+		return c.expr
+	}
+
+	var parts []string
+	if c.table != "" {
+		parts = strings.Split(c.table, ".")
+	}
+	parts = append(parts, c.name)
+	for i, part := range parts {
+		parts[i] = quoteIdent(part)
+	}
+	name := strings.Join(parts, ".")
+
+	if len(c.jsonPath) > 0 {
+		return fmt.Sprintf("json_extract(%s, '$.%s')", name, strings.Join(c.jsonPath, "."))
+	}
+	return name
+}
+
+// compare usa IS NOT en las negaciones de la igualdad para que las filas con
+// NULL se comporten igual que en memoria y no desaparezcan.
+func (sqliteDialect) compare(column string, op parse.Operator, negative bool, placeholder string) string {
+	switch {
+	case op == parse.OpEqual && negative, op == parse.OpNotEqual && !negative:
+		return fmt.Sprintf("%s IS NOT %s", column, placeholder)
+	case op == parse.OpNotEqual && negative:
+		return fmt.Sprintf("%s IS %s", column, placeholder)
+	case negative:
+		return fmt.Sprintf("NOT %s %s %s", column, op, placeholder)
+	}
+	return fmt.Sprintf("%s %s %s", column, op, placeholder)
+}
+
+// contains necesita indicar el escape explícitamente porque SQLite no tiene
+// ninguno por defecto en los patrones LIKE.
+func (sqliteDialect) contains(column string, negative bool, placeholder string) string {
+	var not string
+	if negative {
+		not = "NOT "
+	}
+	return fmt.Sprintf(`%s%s LIKE %s ESCAPE '\'`, not, column, placeholder)
+}
+
+// value codifica los booleanos como enteros y las fechas como texto, que es
+// como se guardan en SQLite al no tener tipos propios para ellos.
+func (sqliteDialect) value(val interface{}) interface{} {
+	switch v := val.(type) {
+	case bool:
+		if v {
+			return int64(1)
+		}
+		return int64(0)
+
+	case time.Time:
+		return v.UTC().Format(SQLiteTimeFormat)
+	}
+	return val
+}
+
+func (sqliteDialect) rowValues() bool {
+	return true
+}
+
+func quoteIdent(name string) string {
+	return `"` + strings.Replace(name, `"`, `""`, -1) + `"`
+}
+
+// escapeLike escapa los caracteres especiales de los patrones LIKE usando la
+// barra invertida, que es el escape por defecto tanto en MySQL como en
+// PostgreSQL y el que se declara explícitamente en SQLite.
+func escapeLike(s string) string {
+	s = strings.Replace(s, `\`, `\\`, -1)
+	s = strings.Replace(s, `%`, `\%`, -1)
+	s = strings.Replace(s, `_`, `\_`, -1)
+	return s
+}
@@ -2,8 +2,9 @@ package expr
 
 import (
 	"fmt"
+	"regexp"
+	"strconv"
 	"strings"
-	"unicode"
 
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -13,11 +14,50 @@ import (
 	"github.com/altipla-consulting/expr/parse"
 )
 
+// MaxInValues es el número máximo de valores que se aceptan en los operadores
+// de conjuntos como IN.
+var MaxInValues = 100
+
 type Filter struct {
-	name      string
-	required  bool
-	operators []parse.Operator
-	eval      func(value parse.Node) (interface{}, error)
+	name        string
+	aliases     []string
+	required    bool
+	operators   []parse.Operator
+	eval        func(value parse.Node) (interface{}, error)
+	min, max    *int64
+	numeric     bool
+	maxValues   int
+	column      column
+	columnType  columnType
+	deprecated  string
+	description string
+}
+
+func (f *Filter) Name() string {
+	return f.name
+}
+
+func (f *Filter) Aliases() []string {
+	return f.aliases
+}
+
+func (f *Filter) Description() string {
+	return f.description
+}
+
+// Deprecated devuelve el mensaje de aviso del filtro o una cadena vacía si se
+// puede seguir usando con normalidad.
+func (f *Filter) Deprecated() string {
+	return f.deprecated
+}
+
+func (f *Filter) sqlColumn(dialect Dialect) string {
+	c := f.column
+	if c.isZero() {
+		c = parseColumn(sqlizeName(f.name))
+	}
+	c.typ = f.columnType
+	return dialect.column(c)
 }
 
 func (f *Filter) hasOperator(op parse.Operator) bool {
@@ -29,55 +69,293 @@ func (f *Filter) hasOperator(op parse.Operator) bool {
 	return false
 }
 
+// evalArg evalúa el argumento de la expresión. Los operadores de conjuntos
+// reciben una lista y devuelven todos sus valores evaluados.
+func (f *Filter) evalArg(expr *parse.ExprNode) (interface{}, error) {
+	list, ok := expr.Val.(*parse.ListNode)
+	if !ok {
+		return f.eval(expr.Val)
+	}
+
+	max := MaxInValues
+	if f.maxValues > 0 {
+		max = f.maxValues
+	}
+	if len(list.Vals) > max {
+		return nil, status.Errorf(codes.InvalidArgument, "too many values for field %v: %d > %d", f.name, len(list.Vals), max)
+	}
+
+	vals := make([]interface{}, len(list.Vals))
+	for i, node := range list.Vals {
+		val, err := f.eval(node)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		vals[i] = val
+	}
+	return vals, nil
+}
+
 type Filters []*Filter
 
-func (fs Filters) parseQuery(query string) (*parse.AndNode, map[string]*Filter, error) {
+// QueryError se devuelve cuando la consulta no tiene una sintaxis correcta. Es
+// un error InvalidArgument de gRPC que además conserva el error del parser con
+// la posición exacta para poder señalarla en el frontend.
+type QueryError struct {
+	Syntax *parse.SyntaxError
+}
+
+func (e *QueryError) Error() string {
+	return e.GRPCStatus().Err().Error()
+}
+
+// As permite extraer el error del parser con errors.As. No se implementa Unwrap
+// para que errors.Cause se quede en este error y conserve el código de gRPC.
+func (e *QueryError) As(target interface{}) bool {
+	if t, ok := target.(**parse.SyntaxError); ok {
+		*t = e.Syntax
+		return true
+	}
+	return false
+}
+
+func (e *QueryError) GRPCStatus() *status.Status {
+	return status.Newf(codes.InvalidArgument, "invalid filter expression: %s", e.Syntax)
+}
+
+func parseFilter(query string) (*parse.AndNode, error) {
 	root, err := parse.Parse(query)
 	if err != nil {
-		return nil, nil, status.Errorf(codes.InvalidArgument, "invalid filter expression: %s", err)
+		if serr, ok := err.(*parse.SyntaxError); ok {
+			return nil, &QueryError{Syntax: serr}
+		}
+		return nil, status.Errorf(codes.InvalidArgument, "invalid filter expression: %s", err)
+	}
+	return root, nil
+}
+
+func (fs Filters) parseQuery(query string) (*parse.AndNode, map[string]*Filter, error) {
+	root, err := parseFilter(query)
+	if err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+
+	filters, err := fs.checkQuery(root)
+	if err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	return root, filters, nil
+}
+
+// Validate comprueba una consulta ya construida, por ejemplo con Build, igual
+// que se comprueban las consultas de texto.
+func (fs Filters) Validate(root *parse.AndNode) error {
+	_, err := fs.checkQuery(root)
+	return errors.Trace(err)
+}
+
+func (fs Filters) checkQuery(root *parse.AndNode) (map[string]*Filter, error) {
+	if root == nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid filter expression: empty tree")
 	}
 
 	filters := make(map[string]*Filter)
 	for _, f := range fs {
 		filters[f.name] = f
+		for _, alias := range f.aliases {
+			filters[alias] = f
+		}
 	}
 
-	present := make(map[string]bool)
-	for _, expr := range root.Nodes {
+	if err := checkTree(root); err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	err := walkExprs(root, func(expr *parse.ExprNode) error {
 		f := filters[expr.Field.Name]
 		if f == nil {
-			return nil, nil, status.Errorf(codes.InvalidArgument, "unknown field in query: %v", expr.Field.Name)
+			return status.Errorf(codes.InvalidArgument, "unknown field in query: %v", expr.Field.Name)
 		}
 
 		if !f.hasOperator(expr.Op.Val) {
-			return nil, nil, status.Errorf(codes.InvalidArgument, "operator not allowed for field %v: %q", expr.Field.Name, expr.Op.Val)
+			return status.Errorf(codes.InvalidArgument, "operator not allowed for field %v: %q", expr.Field.Name, expr.Op.Val)
 		}
 
 		// Validamos que el argumento es legible si tiene.
 		if expr.Op.Val.HasArg() {
-			if _, err := f.eval(expr.Val); err != nil {
-				return nil, nil, errors.Trace(err)
+			val, err := f.evalArg(expr)
+			if err != nil {
+				return errors.Trace(err)
+			}
+
+			// Operators permite activar la búsqueda de texto en cualquier filtro,
+			// pero solo tiene sentido en los que tienen valores de texto.
+			if _, ok := val.(string); expr.Op.Val == parse.OpContains && !ok {
+				return status.Errorf(codes.InvalidArgument, "contains operator requires a string field: %v", expr.Field.Name)
 			}
 		}
 
-		present[expr.Field.Name] = true
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	// Un filtro obligatorio tiene que restringir siempre el resultado, así que
+	// no cuenta si solo aparece dentro de alguna de las ramas de un OR.
+	present := make(map[string]bool)
+	var required func(node parse.Node)
+	required = func(node parse.Node) {
+		switch node := node.(type) {
+		case *parse.AndNode:
+			for _, child := range node.Nodes {
+				required(child)
+			}
+		case *parse.GroupNode:
+			required(node.Expr)
+		case *parse.ExprNode:
+			present[filters[node.Field.Name].name] = true
+		}
 	}
+	required(root)
 
 	for _, f := range fs {
 		if f.required && !present[f.name] {
-			return nil, nil, status.Errorf(codes.InvalidArgument, "required filter in query: %v", f.name)
+			return nil, status.Errorf(codes.InvalidArgument, "required filter in query: %v", f.name)
 		}
 	}
 
-	return root, filters, nil
+	return filters, nil
 }
 
-func (fs Filters) ApplySQL(q *database.Collection, query string) (*database.Collection, error) {
+// checkTree comprueba que el árbol tiene la misma forma que los que genera el
+// parser. Los de texto siempre la tienen, pero los construidos a mano no.
+func checkTree(node parse.Node) error {
+	switch node := node.(type) {
+	case *parse.AndNode:
+		for _, child := range node.Nodes {
+			if _, ok := child.(*parse.AndNode); ok {
+				return status.Errorf(codes.InvalidArgument, "invalid filter expression: nested sequence without group")
+			}
+			if err := checkTree(child); err != nil {
+				return errors.Trace(err)
+			}
+		}
+		return nil
+
+	case *parse.OrNode:
+		if len(node.Nodes) < 2 {
+			return status.Errorf(codes.InvalidArgument, "invalid filter expression: OR requires two terms")
+		}
+		for _, child := range node.Nodes {
+			switch child.(type) {
+			case *parse.GroupNode, *parse.ExprNode:
+			default:
+				return status.Errorf(codes.InvalidArgument, "invalid filter expression: unexpected term in OR: %v", child)
+			}
+			if err := checkTree(child); err != nil {
+				return errors.Trace(err)
+			}
+		}
+		return nil
+
+	case *parse.GroupNode:
+		if node.Expr == nil || len(node.Expr.Nodes) == 0 {
+			return status.Errorf(codes.InvalidArgument, "invalid filter expression: empty group")
+		}
+		return checkTree(node.Expr)
+
+	case *parse.ExprNode:
+		if node.Field == nil || node.Op == nil {
+			return status.Errorf(codes.InvalidArgument, "invalid filter expression: incomplete comparison")
+		}
+		if !node.Op.Val.Valid() {
+			return status.Errorf(codes.InvalidArgument, "invalid filter expression: unknown operator: %v", node.Op.Val)
+		}
+		if node.Op.Val.HasArg() == (node.Val == nil) {
+			return status.Errorf(codes.InvalidArgument, "invalid filter expression: wrong argument for operator %v in field %v", node.Op.Val, node.Field.Name)
+		}
+		list, isList := node.Val.(*parse.ListNode)
+		if node.Val != nil && isList != (node.Op.Val == parse.OpIn) {
+			return status.Errorf(codes.InvalidArgument, "invalid filter expression: wrong argument for operator %v in field %v", node.Op.Val, node.Field.Name)
+		}
+		if !isList {
+			if node.Val == nil {
+				return nil
+			}
+			return checkValue(node.Field.Name, node.Val)
+		}
+		if len(list.Vals) == 0 {
+			return status.Errorf(codes.InvalidArgument, "invalid filter expression: empty list of values in field %v", node.Field.Name)
+		}
+		for _, val := range list.Vals {
+			if err := checkValue(node.Field.Name, val); err != nil {
+				return errors.Trace(err)
+			}
+		}
+		return nil
+	}
+
+	return status.Errorf(codes.InvalidArgument, "invalid filter expression: unexpected node: %v", node)
+}
+
+var (
+	floatRe    = regexp.MustCompile(`^[+-]?[0-9]+(\.[0-9]+)?([eE][+-]?[0-9]+)?$`)
+	constantRe = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+)
+
+// checkValue comprueba que un valor se puede escribir tal cual en una consulta
+// de texto, igual que los que genera el lexer.
+func checkValue(field string, node parse.Node) error {
+	switch node := node.(type) {
+	case *parse.StringNode:
+		if _, err := strconv.Unquote(node.Quoted); err != nil || !strings.HasPrefix(node.Quoted, `"`) {
+			return status.Errorf(codes.InvalidArgument, "invalid filter expression: invalid string in field %v: %s", field, node.Quoted)
+		}
+		return nil
+
+	case *parse.NumberNode:
+		return nil
+
+	case *parse.FloatNode:
+		if !floatRe.MatchString(node.Text) {
+			return status.Errorf(codes.InvalidArgument, "invalid filter expression: invalid number in field %v: %s", field, node.Text)
+		}
+		if _, err := strconv.ParseFloat(node.Text, 64); err != nil {
+			return status.Errorf(codes.InvalidArgument, "invalid filter expression: invalid number in field %v: %s", field, node.Text)
+		}
+		return nil
+
+	case *parse.ConstantNode:
+		if !constantRe.MatchString(node.Name) {
+			return status.Errorf(codes.InvalidArgument, "invalid filter expression: invalid constant in field %v: %s", field, node.Name)
+		}
+		return nil
+	}
+
+	return status.Errorf(codes.InvalidArgument, "invalid filter expression: unexpected value in field %v: %v", field, node)
+}
+
+func (fs Filters) ApplySQL(q *database.Collection, query string, opts ...SQLOption) (*database.Collection, error) {
+	options := &sqlOptions{
+		dialect:          MySQL,
+		placeholderStart: 1,
+	}
+	for _, opt := range opts {
+		opt(options)
+	}
+
 	root, filters, err := fs.parseQuery(query)
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
-	cond, err := evalSQL(root, filters)
+	e := &sqlEvaluator{
+		dialect: options.dialect,
+		filters: filters,
+		next:    options.placeholderStart,
+		keyset:  options.keyset,
+	}
+	cond, err := e.eval(root)
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
@@ -88,6 +366,45 @@ func (fs Filters) ApplySQL(q *database.Collection, query string) (*database.Coll
 	return q, nil
 }
 
+func (fs Filters) ToSQL(query string, opts ...SQLOption) (string, []interface{}, error) {
+	root, err := parseFilter(query)
+	if err != nil {
+		return "", nil, errors.Trace(err)
+	}
+	return fs.ToSQLNode(root, opts...)
+}
+
+// ToSQLNode es como ToSQL pero recibe una consulta ya construida.
+func (fs Filters) ToSQLNode(root *parse.AndNode, opts ...SQLOption) (string, []interface{}, error) {
+	options := &sqlOptions{
+		dialect:          MySQL,
+		placeholderStart: 1,
+	}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	filters, err := fs.checkQuery(root)
+	if err != nil {
+		return "", nil, errors.Trace(err)
+	}
+	e := &sqlEvaluator{
+		dialect: options.dialect,
+		filters: filters,
+		next:    options.placeholderStart,
+		keyset:  options.keyset,
+	}
+	cond, err := e.eval(root)
+	if err != nil {
+		return "", nil, errors.Trace(err)
+	}
+	if cond == nil {
+		return "", nil, nil
+	}
+
+	return cond.sql, cond.vals, nil
+}
+
 type sqlCondition struct {
 	sql  string
 	vals []interface{}
@@ -96,47 +413,28 @@ type sqlCondition struct {
 func (cond *sqlCondition) SQL() string           { return cond.sql }
 func (cond *sqlCondition) Values() []interface{} { return cond.vals }
 
-func evalSQL(root *parse.AndNode, filters map[string]*Filter) (*sqlCondition, error) {
-	var conds []string
-	var vals []interface{}
-	for _, expr := range root.Nodes {
-		switch expr.Op.Val {
-		case parse.OpExists:
-			if expr.Negative {
-				conds = append(conds, fmt.Sprintf("(%s IS NULL)", sqlizeName(expr.Field.Name)))
-			} else {
-				conds = append(conds, fmt.Sprintf("(%s IS NOT NULL)", sqlizeName(expr.Field.Name)))
-			}
-
-		case parse.OpEqual, parse.OpNotEqual, parse.OpGreaterThan, parse.OpGreaterOrEqualThan, parse.OpLessThan, parse.OpLessOrEqualThan:
-			val, err := filters[expr.Field.Name].eval(expr.Val)
-			if err != nil {
-				return nil, errors.Trace(err)
-			}
-
-			var not string
-			if expr.Negative {
-				not = "NOT "
-			}
-			conds = append(conds, fmt.Sprintf("(%s%s %s ?)", not, sqlizeName(expr.Field.Name), expr.Op.Val))
-			vals = append(vals, val)
-
-		case parse.OpContains:
-			val, err := filters[expr.Field.Name].eval(expr.Val)
-			if err != nil {
-				return nil, errors.Trace(err)
-			}
+// sqlEvaluator traduce la consulta a SQL acumulando los valores en el mismo
+// orden en que aparecen sus marcadores.
+type sqlEvaluator struct {
+	dialect Dialect
+	filters map[string]*Filter
+	vals    []interface{}
+	next    int
+	keyset  *keyset
+}
 
-			var not string
-			if expr.Negative {
-				not = "NOT "
-			}
-			conds = append(conds, fmt.Sprintf("(%s%s LIKE ?)", not, sqlizeName(expr.Field.Name)))
-			vals = append(vals, "%"+database.EscapeLike(val.(string))+"%")
+func (e *sqlEvaluator) eval(root *parse.AndNode) (*sqlCondition, error) {
+	conds, err := e.evalSequence(root)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
 
-		default:
-			return nil, errors.Errorf("cannot use operator in SQL queries: %v", expr.Op.Val)
+	if e.keyset != nil {
+		cond, err := e.evalKeyset(e.keyset)
+		if err != nil {
+			return nil, errors.Trace(err)
 		}
+		conds = append(conds, cond)
 	}
 
 	if len(conds) == 0 {
@@ -145,70 +443,388 @@ func evalSQL(root *parse.AndNode, filters map[string]*Filter) (*sqlCondition, er
 
 	return &sqlCondition{
 		sql:  strings.Join(conds, " AND "),
-		vals: vals,
+		vals: e.vals,
 	}, nil
 }
 
-func sqlizeName(s string) string {
-	var result []rune
-	for _, r := range s {
-		if unicode.IsUpper(r) {
-			result = append(result, '_')
+func (e *sqlEvaluator) placeholder(val interface{}) string {
+	e.vals = append(e.vals, val)
+	ph := e.dialect.placeholder(e.next)
+	e.next++
+	return ph
+}
+
+func (e *sqlEvaluator) evalSequence(seq *parse.AndNode) ([]string, error) {
+	var conds []string
+	for _, node := range seq.Nodes {
+		cond, err := e.evalNode(node)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		conds = append(conds, cond)
+	}
+	return conds, nil
+}
+
+// evalNode devuelve siempre la condición entre paréntesis para que se pueda
+// combinar directamente con el resto sin preocuparse de la precedencia.
+func (e *sqlEvaluator) evalNode(node parse.Node) (string, error) {
+	switch node := node.(type) {
+	case *parse.OrNode:
+		conds := make([]string, len(node.Nodes))
+		for i, child := range node.Nodes {
+			cond, err := e.evalNode(child)
+			if err != nil {
+				return "", errors.Trace(err)
+			}
+			conds[i] = cond
+		}
+		return "(" + strings.Join(conds, " OR ") + ")", nil
+
+	case *parse.GroupNode:
+		conds, err := e.evalSequence(node.Expr)
+		if err != nil {
+			return "", errors.Trace(err)
+		}
+		cond := conds[0]
+		if len(conds) > 1 {
+			cond = "(" + strings.Join(conds, " AND ") + ")"
+		}
+		if node.Negative {
+			// Las comparaciones con NULL dan NULL y su negación también. En memoria
+			// se consideran falsas, así que lo forzamos antes de negar el grupo.
+			cond = "(NOT COALESCE(" + cond + ", FALSE))"
+		}
+		return cond, nil
+
+	case *parse.ExprNode:
+		return e.evalExpr(node)
+	}
+
+	return "", errors.Errorf("cannot use node in SQL queries: %v", node)
+}
+
+func (e *sqlEvaluator) evalExpr(expr *parse.ExprNode) (string, error) {
+	f := e.filters[expr.Field.Name]
+	column := f.sqlColumn(e.dialect)
+
+	switch expr.Op.Val {
+	case parse.OpExists:
+		if expr.Negative {
+			return fmt.Sprintf("(%s IS NULL)", column), nil
+		}
+		return fmt.Sprintf("(%s IS NOT NULL)", column), nil
+
+	case parse.OpEqual, parse.OpNotEqual, parse.OpGreaterThan, parse.OpGreaterOrEqualThan, parse.OpLessThan, parse.OpLessOrEqualThan:
+		val, err := f.eval(expr.Val)
+		if err != nil {
+			return "", errors.Trace(err)
+		}
+		cond := e.dialect.compare(column, expr.Op.Val, expr.Negative, e.placeholder(e.dialect.value(val)))
+		if expr.Negative && expr.Op.Val != parse.OpEqual && expr.Op.Val != parse.OpNotEqual {
+			cond = orNull(column, cond)
+		}
+		return "(" + cond + ")", nil
+
+	case parse.OpContains:
+		val, err := f.eval(expr.Val)
+		if err != nil {
+			return "", errors.Trace(err)
+		}
+		str, ok := val.(string)
+		if !ok {
+			return "", errors.Errorf("cannot use contains operator with non-string field: %v", expr.Field.Name)
+		}
+		cond := e.dialect.contains(column, expr.Negative, e.placeholder("%"+escapeLike(str)+"%"))
+		if expr.Negative {
+			cond = orNull(column, cond)
+		}
+		return "(" + cond + ")", nil
+
+	case parse.OpIn:
+		val, err := f.evalArg(expr)
+		if err != nil {
+			return "", errors.Trace(err)
 		}
-		result = append(result, unicode.ToLower(r))
+
+		var placeholders []string
+		for _, v := range val.([]interface{}) {
+			placeholders = append(placeholders, e.placeholder(e.dialect.value(v)))
+		}
+
+		cond := fmt.Sprintf("%s IN (%s)", column, strings.Join(placeholders, ", "))
+		if expr.Negative {
+			cond = orNull(column, "NOT "+cond)
+		}
+		return "(" + cond + ")", nil
 	}
-	return string(result)
+
+	return "", errors.Errorf("cannot use operator in SQL queries: %v", expr.Op.Val)
+}
+
+// orNull añade las filas con NULL a una comparación negada. En memoria un campo
+// que no existe no cumple la comparación y por tanto sí su negación, mientras
+// que en SQL la negación de NULL sigue siendo NULL.
+func orNull(column, cond string) string {
+	return column + " IS NULL OR " + cond
 }
 
 type Matcher func(value map[string]interface{}) bool
 
+// MatcherE es como Matcher pero devuelve un *MatchError si algún valor del dato
+// no tiene un tipo compatible con el filtro en lugar de considerarlo distinto.
+type MatcherE func(value map[string]interface{}) (bool, error)
+
+type MatchError struct {
+	Field string
+	Value interface{}
+}
+
+func (e *MatchError) Error() string {
+	return fmt.Sprintf("cannot match value of type %T in field %v", e.Value, e.Field)
+}
+
 func (fs Filters) Matcher(query string) (Matcher, error) {
-	root, filters, err := fs.parseQuery(query)
+	matcher, err := fs.MatcherE(query)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	return func(value map[string]interface{}) bool {
+		result, err := matcher(value)
+		return err == nil && result
+	}, nil
+}
+
+func (fs Filters) MatcherE(query string) (MatcherE, error) {
+	root, filters, err := fs.parseMatcherQuery(query)
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
 
-	for _, expr := range root.Nodes {
+	return func(value map[string]interface{}) (bool, error) {
+		return matchNode(root, filters, func(name string) (interface{}, error) {
+			return value[name], nil
+		})
+	}, nil
+}
+
+func (fs Filters) parseMatcherQuery(query string) (*parse.AndNode, map[string]*Filter, error) {
+	root, filters, err := fs.parseQuery(query)
+	if err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+
+	err = walkExprs(root, func(expr *parse.ExprNode) error {
 		switch expr.Op.Val {
-		case parse.OpEqual, parse.OpNotEqual, parse.OpContains, parse.OpExists:
+		case parse.OpEqual, parse.OpNotEqual, parse.OpContains, parse.OpExists, parse.OpIn:
+		case parse.OpGreaterThan, parse.OpGreaterOrEqualThan, parse.OpLessThan, parse.OpLessOrEqualThan:
 		default:
-			return nil, errors.Errorf("cannot use operator in matcher queries: %v", expr.Op.Val)
+			return errors.Errorf("cannot use operator in matcher queries: %v", expr.Op.Val)
 		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, errors.Trace(err)
 	}
 
-	return func(value map[string]interface{}) bool {
-		for _, expr := range root.Nodes {
-			// Podemos ignorar el error porque ya se comprueban antes al parsear la query.
-			want, _ := filters[expr.Field.Name].eval(expr.Val)
+	return root, filters, nil
+}
 
-			got, exists := value[expr.Field.Name]
+// valueLookup busca el valor de un campo del dato que se está comprobando.
+type valueLookup func(name string) (interface{}, error)
 
-			// Las enumeraciones se comparan como strings, así que las convertimos.
-			if enumv, ok := got.(enumValue); ok {
-				got = enumv.String()
+func matchNode(node parse.Node, filters map[string]*Filter, lookup valueLookup) (bool, error) {
+	switch node := node.(type) {
+	case *parse.AndNode:
+		// Si no encontramos lo que necesitamos podemos parar de comprobar
+		// condiciones y salirnos ya.
+		for _, child := range node.Nodes {
+			result, err := matchNode(child, filters, lookup)
+			if err != nil {
+				return false, errors.Trace(err)
+			}
+			if !result {
+				return false, nil
+			}
+		}
+		return true, nil
+
+	case *parse.OrNode:
+		for _, child := range node.Nodes {
+			result, err := matchNode(child, filters, lookup)
+			if err != nil {
+				return false, errors.Trace(err)
 			}
+			if result {
+				return true, nil
+			}
+		}
+		return false, nil
+
+	case *parse.GroupNode:
+		result, err := matchNode(node.Expr, filters, lookup)
+		if err != nil {
+			return false, errors.Trace(err)
+		}
+		return node.Negative != result, nil
+
+	case *parse.ExprNode:
+		return matchExpr(node, filters, lookup)
+	}
+
+	panic("should not reach here")
+}
+
+func matchExpr(expr *parse.ExprNode, filters map[string]*Filter, lookup valueLookup) (bool, error) {
+	f := filters[expr.Field.Name]
+
+	// Podemos ignorar el error porque ya se comprueban antes al parsear la query.
+	want, _ := f.evalArg(expr)
+
+	raw, err := lookup(f.name)
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+
+	var result bool
+	if values, ok := raw.(repeatedValue); ok {
+		result, err = matchRepeated(f, expr.Op.Val, want, values)
+	} else {
+		result, err = matchValue(f, expr.Op.Val, want, raw)
+	}
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+
+	return expr.Negative != result, nil
+}
+
+// repeatedValue lo devuelven las búsquedas de valores cuando el campo es una
+// lista de elementos en lugar de un valor único.
+type repeatedValue []interface{}
+
+// matchRepeated cumple la condición si cualquiera de los elementos de la lista
+// la cumple. La desigualdad es la excepción: se cumple si ningún elemento es igual.
+func matchRepeated(f *Filter, op parse.Operator, want interface{}, values repeatedValue) (bool, error) {
+	switch op {
+	case parse.OpExists:
+		return len(values) > 0, nil
+
+	case parse.OpNotEqual:
+		result, err := matchRepeated(f, parse.OpEqual, want, values)
+		if err != nil {
+			return false, errors.Trace(err)
+		}
+		return !result, nil
+	}
+
+	for _, value := range values {
+		result, err := matchValue(f, op, want, value)
+		if err != nil {
+			return false, errors.Trace(err)
+		}
+		if result {
+			return true, nil
+		}
+	}
+	return false, nil
+}
 
-			var result bool
-			switch expr.Op.Val {
+func matchValue(f *Filter, op parse.Operator, want, raw interface{}) (bool, error) {
+	// Los valores nulos se comportan como si el campo no existiera, igual que
+	// las columnas NULL en SQL.
+	got := normalizeValue(raw)
+	exists := got != nil
+
+	var result bool
+	switch op {
+	case parse.OpExists:
+		result = exists
+
+	case parse.OpEqual, parse.OpNotEqual, parse.OpGreaterThan, parse.OpGreaterOrEqualThan, parse.OpLessThan, parse.OpLessOrEqualThan:
+		if exists {
+			cmp, ok := compareValues(want, got)
+			if !ok {
+				return false, &MatchError{Field: f.name, Value: raw}
+			}
+			switch op {
 			case parse.OpEqual:
-				result = (want == got)
+				result = cmp == 0
 			case parse.OpNotEqual:
-				result = (want != got)
-			case parse.OpContains:
-				result = strings.Contains(got.(string), want.(string))
-			case parse.OpExists:
-				result = exists
-			default:
-				panic("should not reach here")
+				result = cmp != 0
+			case parse.OpGreaterThan:
+				result = cmp > 0
+			case parse.OpGreaterOrEqualThan:
+				result = cmp >= 0
+			case parse.OpLessThan:
+				result = cmp < 0
+			case parse.OpLessOrEqualThan:
+				result = cmp <= 0
 			}
+		}
+		if !exists && op == parse.OpNotEqual {
+			result = true
+		}
 
-			// Si no encontramos lo que necesitamos podemos parar de comprobar
-			// condiciones y salirnos ya.
-			if expr.Negative == result {
-				return false
+	case parse.OpContains:
+		if exists {
+			str, ok := got.(string)
+			if !ok {
+				return false, &MatchError{Field: f.name, Value: raw}
+			}
+			substr, ok := want.(string)
+			if !ok {
+				return false, errors.Errorf("cannot use contains operator with non-string field: %v", f.name)
 			}
+			result = strings.Contains(str, substr)
 		}
 
-		return true
-	}, nil
+	case parse.OpIn:
+		if exists {
+			for _, w := range want.([]interface{}) {
+				cmp, ok := compareValues(w, got)
+				if !ok {
+					return false, &MatchError{Field: f.name, Value: raw}
+				}
+				if cmp == 0 {
+					result = true
+					break
+				}
+			}
+		}
+
+	default:
+		panic("should not reach here")
+	}
+
+	return result, nil
+}
+
+// walkExprs recorre todas las comparaciones de la consulta, estén dentro de
+// grupos o de disyunciones.
+func walkExprs(node parse.Node, fn func(expr *parse.ExprNode) error) error {
+	switch node := node.(type) {
+	case *parse.AndNode:
+		for _, child := range node.Nodes {
+			if err := walkExprs(child, fn); err != nil {
+				return errors.Trace(err)
+			}
+		}
+
+	case *parse.OrNode:
+		for _, child := range node.Nodes {
+			if err := walkExprs(child, fn); err != nil {
+				return errors.Trace(err)
+			}
+		}
+
+	case *parse.GroupNode:
+		return walkExprs(node.Expr, fn)
+
+	case *parse.ExprNode:
+		return fn(node)
+	}
+
+	return nil
 }
@@ -1,11 +1,18 @@
 package expr
 
 import (
+	goerrors "errors"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"libs.altipla.consulting/errors"
 
+	"github.com/altipla-consulting/expr/parse"
 	pb "github.com/altipla-consulting/expr/testdata/foo"
 )
 
@@ -16,6 +23,9 @@ func TestEvalSQL(t *testing.T) {
 		TimestampParam("ts"),
 		BoolParam("boolUppercase"),
 		StringParam("str"),
+		FloatParam("rating"),
+		DecimalParam("price"),
+		IntParam("stock"),
 	}
 
 	tests := []struct {
@@ -35,12 +45,12 @@ func TestEvalSQL(t *testing.T) {
 		},
 		{
 			query:    `id!=4 enum=FOOENUM_FIRST`,
-			expected: `(id != ?) AND (enum = ?)`,
+			expected: `(NOT (id <=> ?)) AND (enum = ?)`,
 			vals:     []interface{}{4, "FOOENUM_FIRST"},
 		},
 		{
 			query:    `-id=3`,
-			expected: `(NOT id = ?)`,
+			expected: `(NOT (id <=> ?))`,
 			vals:     []interface{}{3},
 		},
 		{
@@ -93,22 +103,100 @@ func TestEvalSQL(t *testing.T) {
 			expected: `(str LIKE ?)`,
 			vals:     []interface{}{`%foo\%bar%`},
 		},
+		{
+			query:    `id=3 OR id=4`,
+			expected: `((id = ?) OR (id = ?))`,
+			vals:     []interface{}{3, 4},
+		},
+		{
+			query:    `id=3 OR id=4 enum=FOOENUM_FIRST`,
+			expected: `((id = ?) OR (id = ?)) AND (enum = ?)`,
+			vals:     []interface{}{3, 4, "FOOENUM_FIRST"},
+		},
+		{
+			query:    `(id=3 enum=FOOENUM_FIRST) OR str:foo`,
+			expected: `(((id = ?) AND (enum = ?)) OR (str LIKE ?))`,
+			vals:     []interface{}{3, "FOOENUM_FIRST", "%foo%"},
+		},
+		{
+			query:    `NOT (id=3 OR id=4) AND enum=FOOENUM_FIRST`,
+			expected: `(NOT COALESCE(((id = ?) OR (id = ?)), FALSE)) AND (enum = ?)`,
+			vals:     []interface{}{3, 4, "FOOENUM_FIRST"},
+		},
+		{
+			query:    `NOT id=3`,
+			expected: `(NOT (id <=> ?))`,
+			vals:     []interface{}{3},
+		},
+		{
+			query:    `id IN (3, 4, 5)`,
+			expected: `(id IN (?, ?, ?))`,
+			vals:     []interface{}{3, 4, 5},
+		},
+		{
+			query:    `-enum IN (FOOENUM_FIRST, FOOENUM_SECOND) str IN (foo)`,
+			expected: `(enum IS NULL OR NOT enum IN (?, ?)) AND (str IN (?))`,
+			vals:     []interface{}{"FOOENUM_FIRST", "FOOENUM_SECOND", "foo"},
+		},
+		{
+			query:    `rating>=4.5 rating<5`,
+			expected: `(rating >= ?) AND (rating < ?)`,
+			vals:     []interface{}{4.5, 5.0},
+		},
+		{
+			query:    `price<=10.50 price>1.25e1 price!=+3`,
+			expected: `(price <= ?) AND (price > ?) AND (NOT (price <=> ?))`,
+			vals:     []interface{}{Decimal("10.50"), Decimal("12.5"), Decimal("3")},
+		},
+		{
+			query:    `stock>-5 stock<=10 stock IN (1, 2)`,
+			expected: `(stock > ?) AND (stock <= ?) AND (stock IN (?, ?))`,
+			vals:     []interface{}{-5, 10, 1, 2},
+		},
+		{
+			query:    `(id=3)`,
+			expected: `(id = ?)`,
+			vals:     []interface{}{3},
+		},
 	}
 	for i, test := range tests {
-		root, filters, err := filters.parseQuery(test.query)
-		require.NoError(t, err)
-		cond, err := evalSQL(root, filters)
+		sql, vals, err := filters.ToSQL(test.query)
 		require.NoError(t, err)
 
-		require.Equal(t, cond.sql, test.expected, "test %v: [%v]", i, test.query)
+		require.Equal(t, sql, test.expected, "test %v: [%v]", i, test.query)
 
-		require.Len(t, cond.vals, len(test.vals))
-		for j, val := range cond.vals {
+		require.Len(t, vals, len(test.vals))
+		for j, val := range vals {
 			require.EqualValues(t, val, test.vals[j], "test %v, value %v: [%v]", i, j, test.query)
 		}
 	}
 }
 
+func TestFiltersSyntaxError(t *testing.T) {
+	filters := Filters{
+		IDParam("id"),
+	}
+
+	_, err := filters.Matcher(`id=3 id=)`)
+	require.Error(t, err)
+	require.Equal(t, codes.InvalidArgument, status.Code(errors.Cause(err)))
+
+	var serr *parse.SyntaxError
+	require.True(t, goerrors.As(errors.Cause(err), &serr))
+	require.Equal(t, 8, serr.Offset)
+	require.Equal(t, 1, serr.Line)
+	require.Equal(t, 9, serr.Column)
+	require.Equal(t, "id=3 id=)\n        ^", serr.Caret())
+
+	_, _, err = filters.ToSQL(`id=3 (`)
+	require.True(t, goerrors.As(errors.Cause(err), &serr))
+	require.Equal(t, 6, serr.Offset)
+	require.Equal(t, codes.InvalidArgument, status.Code(errors.Cause(err)))
+
+	_, _, err = filters.ToSQL(`unknown=3`)
+	require.False(t, goerrors.As(errors.Cause(err), &serr))
+}
+
 func TestMatcher(t *testing.T) {
 	filters := Filters{
 		IDParam("foo"),
@@ -163,3 +251,791 @@ func TestMatcherTimestampExists(t *testing.T) {
 	data = make(map[string]interface{})
 	require.False(t, matcher(data))
 }
+
+func TestMatcherOr(t *testing.T) {
+	filters := Filters{
+		IDParam("foo"),
+		StringParam("bar"),
+	}
+
+	matcher, err := filters.Matcher(`(foo=3 OR foo=4) bar:baz`)
+	require.NoError(t, err)
+
+	require.True(t, matcher(map[string]interface{}{"foo": int64(3), "bar": "baz"}))
+	require.True(t, matcher(map[string]interface{}{"foo": int64(4), "bar": "foobaz"}))
+	require.False(t, matcher(map[string]interface{}{"foo": int64(5), "bar": "baz"}))
+	require.False(t, matcher(map[string]interface{}{"foo": int64(3), "bar": "qux"}))
+
+	matcher, err = filters.Matcher(`NOT (foo=3 OR foo=4) AND bar:baz`)
+	require.NoError(t, err)
+
+	require.False(t, matcher(map[string]interface{}{"foo": int64(3), "bar": "baz"}))
+	require.True(t, matcher(map[string]interface{}{"foo": int64(5), "bar": "baz"}))
+}
+
+func TestParseQueryRequiredInsideOr(t *testing.T) {
+	filters := Filters{
+		IDParam("foo", Required()),
+		IDParam("bar"),
+	}
+
+	_, _, err := filters.parseQuery(`foo=3 OR bar=4`)
+	require.Error(t, err)
+
+	_, _, err = filters.parseQuery(`(foo=3 bar=4) OR bar=5`)
+	require.Error(t, err)
+
+	_, _, err = filters.parseQuery(`(foo=3) bar=4 OR bar=5`)
+	require.NoError(t, err)
+}
+
+func TestMatcherIn(t *testing.T) {
+	filters := Filters{
+		IDParam("foo"),
+		EnumParam("bar", pb.FooEnum_value),
+	}
+
+	matcher, err := filters.Matcher(`foo IN (3, 4) bar IN (FOOENUM_FIRST)`)
+	require.NoError(t, err)
+
+	require.True(t, matcher(map[string]interface{}{"foo": int64(3), "bar": pb.FooEnum_FOOENUM_FIRST}))
+	require.True(t, matcher(map[string]interface{}{"foo": int64(4), "bar": pb.FooEnum_FOOENUM_FIRST}))
+	require.False(t, matcher(map[string]interface{}{"foo": int64(5), "bar": pb.FooEnum_FOOENUM_FIRST}))
+	require.False(t, matcher(map[string]interface{}{"foo": int64(3), "bar": pb.FooEnum_FOOENUM_SECOND}))
+}
+
+func TestParseQueryMaxInValues(t *testing.T) {
+	defer func(max int) { MaxInValues = max }(MaxInValues)
+	MaxInValues = 2
+
+	filters := Filters{
+		IDParam("foo"),
+	}
+
+	_, _, err := filters.parseQuery(`foo IN (1, 2)`)
+	require.NoError(t, err)
+
+	_, _, err = filters.parseQuery(`foo IN (1, 2, 3)`)
+	require.Error(t, err)
+}
+
+func TestMatcherDecimal(t *testing.T) {
+	filters := Filters{
+		DecimalParam("price"),
+		FloatParam("rating"),
+	}
+
+	matcher, err := filters.Matcher(`price=10.50 rating=4.5`)
+	require.NoError(t, err)
+
+	require.True(t, matcher(map[string]interface{}{"price": "10.5", "rating": 4.5}))
+	require.True(t, matcher(map[string]interface{}{"price": 10.5, "rating": 4.5}))
+	require.False(t, matcher(map[string]interface{}{"price": "10.51", "rating": 4.5}))
+	require.False(t, matcher(map[string]interface{}{"price": "10.50", "rating": 4.0}))
+	require.False(t, matcher(map[string]interface{}{"price": "foo", "rating": 4.5}))
+}
+
+func TestDecimalFromText(t *testing.T) {
+	tests := map[string]Decimal{
+		"10.50":    "10.50",
+		"+3.0":     "3.0",
+		"-0.25":    "-0.25",
+		"1.25e1":   "12.5",
+		"1.250e1":  "12.50",
+		"5e3":      "5000",
+		"5E-3":     "0.005",
+		"-1.5e+2":  "-150",
+		"12.345e1": "123.45",
+	}
+	for text, expected := range tests {
+		d, ok := decimalFromText(text)
+		require.True(t, ok, text)
+		require.Equal(t, expected, d, text)
+	}
+
+	for _, text := range []string{"1e-9999999", "1e-999999", "1e999999", "1e101", "1.5e-100"} {
+		_, ok := decimalFromText(text)
+		require.False(t, ok, text)
+	}
+}
+
+func TestDecimalParamOutOfRange(t *testing.T) {
+	filters := Filters{
+		DecimalParam("price"),
+	}
+
+	for _, query := range []string{`price=1e-9999999`, `price=1e-999999`, `price>1e999`} {
+		_, _, err := filters.parseQuery(query)
+		require.Error(t, err, query)
+		require.Equal(t, codes.InvalidArgument, status.Code(errors.Cause(err)), query)
+	}
+}
+
+func TestIntParamBounds(t *testing.T) {
+	filters := Filters{
+		IntParam("age", Min(0), Max(150)),
+	}
+
+	_, _, err := filters.parseQuery(`age>=0 age<=150`)
+	require.NoError(t, err)
+
+	_, _, err = filters.parseQuery(`age>-1`)
+	require.Error(t, err)
+
+	_, _, err = filters.parseQuery(`age<151`)
+	require.Error(t, err)
+
+	_, _, err = filters.parseQuery(`age IN (3, 200)`)
+	require.Error(t, err)
+
+	_, _, err = filters.parseQuery(`age="3"`)
+	require.Error(t, err)
+}
+
+func TestNumericParamBounds(t *testing.T) {
+	filters := Filters{
+		IDParam("id", Max(100)),
+		FloatParam("rating", Min(0), Max(5)),
+		DecimalParam("price", Min(1)),
+	}
+
+	_, _, err := filters.parseQuery(`id=100 rating>=0 rating<=5 rating=4.5 price>=1 price=1.00 price=10.50`)
+	require.NoError(t, err)
+
+	for _, query := range []string{`id=101`, `id IN (3, 200)`, `rating=99`, `rating<-0.5`, `rating>5.01`, `price<0.99`, `price=1e-1`} {
+		_, _, err := filters.parseQuery(query)
+		require.Error(t, err, query)
+		require.Equal(t, codes.InvalidArgument, status.Code(errors.Cause(err)), query)
+	}
+}
+
+func TestBoundsNonNumericParam(t *testing.T) {
+	require.Panics(t, func() {
+		StringParam("name", Min(3))
+	})
+	require.Panics(t, func() {
+		TimestampParam("createTime", Max(3))
+	})
+	require.Panics(t, func() {
+		EnumParam("status", pb.FooEnum_value, Min(0), Max(1))
+	})
+}
+
+func TestMatcherOrdering(t *testing.T) {
+	filters := Filters{
+		IntParam("stock"),
+		FloatParam("rating"),
+		DecimalParam("price"),
+	}
+
+	matcher, err := filters.Matcher(`stock>3 rating<=4.5 price>=10.50`)
+	require.NoError(t, err)
+
+	require.True(t, matcher(map[string]interface{}{"stock": int64(4), "rating": 4.5, "price": "10.5"}))
+	require.False(t, matcher(map[string]interface{}{"stock": int64(3), "rating": 4.5, "price": "10.5"}))
+	require.False(t, matcher(map[string]interface{}{"stock": int64(4), "rating": 4.6, "price": "10.5"}))
+	require.False(t, matcher(map[string]interface{}{"stock": int64(4), "rating": 4.5, "price": "10.49"}))
+	require.False(t, matcher(map[string]interface{}{"rating": 4.5, "price": "10.5"}))
+}
+
+func TestParamOptions(t *testing.T) {
+	filters := Filters{
+		EnumParam("status", pb.FooEnum_value, Required()),
+		StringParam("name", Operators(parse.OpEqual), Column("full_name"), Alias("fullName")),
+		IDParam("owner", MaxValues(2), Deprecated("use ownerId instead"), Description("Owner of the item")),
+	}
+
+	_, _, err := filters.parseQuery(`name="foo"`)
+	require.Error(t, err)
+
+	_, _, err = filters.parseQuery(`status=FOOENUM_FIRST name:foo`)
+	require.Error(t, err)
+
+	_, _, err = filters.parseQuery(`status=FOOENUM_FIRST owner IN (1, 2, 3)`)
+	require.Error(t, err)
+
+	sql, _, err := filters.ToSQL(`status=FOOENUM_FIRST fullName="foo" owner IN (1, 2)`)
+	require.NoError(t, err)
+	require.Equal(t, `(status = ?) AND (full_name = ?) AND (owner IN (?, ?))`, sql)
+
+	matcher, err := filters.Matcher(`status=FOOENUM_FIRST fullName="foo"`)
+	require.NoError(t, err)
+	require.True(t, matcher(map[string]interface{}{"status": pb.FooEnum_FOOENUM_FIRST, "name": "foo"}))
+
+	require.Equal(t, "owner", filters[2].Name())
+	require.Equal(t, "use ownerId instead", filters[2].Deprecated())
+	require.Equal(t, "Owner of the item", filters[2].Description())
+	require.Equal(t, []string{"fullName"}, filters[1].Aliases())
+	require.Empty(t, filters[1].Deprecated())
+}
+
+func TestContainsRequiresStringField(t *testing.T) {
+	filters := Filters{
+		IDParam("id", Operators(parse.OpContains)),
+		StringParam("name", Operators(parse.OpContains)),
+	}
+
+	_, err := filters.Matcher(`id:3`)
+	require.Error(t, err)
+	require.Equal(t, codes.InvalidArgument, status.Code(errors.Cause(err)))
+
+	_, _, err = filters.ToSQL(`id:3`)
+	require.Error(t, err)
+	require.Equal(t, codes.InvalidArgument, status.Code(errors.Cause(err)))
+
+	_, _, err = filters.ToSQL(`name:3`)
+	require.Error(t, err)
+
+	matcher, err := filters.Matcher(`name:foo`)
+	require.NoError(t, err)
+	require.True(t, matcher(map[string]interface{}{"name": "foobar"}))
+}
+
+func TestSQLColumns(t *testing.T) {
+	filters := Filters{
+		IDParam("userID"),
+		StringParam("HTTPStatus"),
+		StringParam("author.displayName"),
+		StringParam("owner", Column("users.name")),
+		StringParam("city", JSONColumn("data", "address", "city")),
+		IntParam("year", ColumnExpr("YEAR(created_at)")),
+	}
+
+	sql, _, err := filters.ToSQL(`userID=3 HTTPStatus=OK author.displayName:foo owner=bar city=Madrid year>2019`)
+	require.NoError(t, err)
+
+	expected := `(user_id = ?) AND (http_status = ?) AND (author.display_name LIKE ?) AND (users.name = ?) AND ` +
+		`(JSON_UNQUOTE(JSON_EXTRACT(data, '$.address.city')) = ?) AND (YEAR(created_at) > ?)`
+	require.Equal(t, expected, sql)
+}
+
+func TestSqlizeName(t *testing.T) {
+	tests := map[string]string{
+		"id":             "id",
+		"boolUppercase":  "bool_uppercase",
+		"userID":         "user_id",
+		"HTTPStatus":     "http_status",
+		"parentIDFilter": "parent_id_filter",
+		"foo.barBaz":     "foo.bar_baz",
+		"Foo":            "foo",
+	}
+	for name, expected := range tests {
+		require.Equal(t, expected, sqlizeName(name), name)
+	}
+}
+
+func TestColumnValidation(t *testing.T) {
+	require.Panics(t, func() { Column("users.name; DROP TABLE users") })
+	require.Panics(t, func() { Column("users.") })
+	require.Panics(t, func() { JSONColumn("data", "it's") })
+	require.NotPanics(t, func() { Column("app.users.name") })
+}
+
+func TestToSQLPostgreSQL(t *testing.T) {
+	filters := Filters{
+		IDParam("id"),
+		EnumParam("enum", pb.FooEnum_value),
+		TimestampParam("ts"),
+		StringParam("str"),
+		StringParam("owner", Column("users.displayName")),
+		StringParam("city", JSONColumn("data", "address", "city")),
+		IntParam("age", JSONColumn("data", "age")),
+		DecimalParam("price", JSONColumn("data", "price")),
+		BoolParam("active", JSONColumn("data", "flags", "active")),
+		TimestampParam("seen", JSONColumn("data", "seen")),
+	}
+
+	tests := []struct {
+		query    string
+		expected string
+		vals     []interface{}
+	}{
+		{
+			query:    `id=3 enum!=FOOENUM_FIRST`,
+			expected: `("id" = $1) AND ("enum" IS DISTINCT FROM $2)`,
+			vals:     []interface{}{3, "FOOENUM_FIRST"},
+		},
+		{
+			query:    `-id=3 NOT enum!=FOOENUM_FIRST`,
+			expected: `("id" IS DISTINCT FROM $1) AND ("enum" IS NOT DISTINCT FROM $2)`,
+			vals:     []interface{}{3, "FOOENUM_FIRST"},
+		},
+		{
+			query:    `-ts>"2019-03-02" ts:*`,
+			expected: `("ts" IS NULL OR NOT "ts" > $1) AND ("ts" IS NOT NULL)`,
+			vals:     []interface{}{time.Date(2019, time.March, 2, 0, 0, 0, 0, time.UTC)},
+		},
+		{
+			query:    `str:"foo_bar" -owner:baz`,
+			expected: `("str" ILIKE $1) AND ("users"."displayName" IS NULL OR NOT "users"."displayName" ILIKE $2)`,
+			vals:     []interface{}{`%foo\_bar%`, "%baz%"},
+		},
+		{
+			query:    `(id IN (1, 2) OR city=Madrid) str=foo`,
+			expected: `(("id" IN ($1, $2)) OR ("data"->'address'->>'city' = $3)) AND ("str" = $4)`,
+			vals:     []interface{}{1, 2, "Madrid", "foo"},
+		},
+		{
+			query:    `age>9 price<=10.5`,
+			expected: `(("data"->>'age')::bigint > $1) AND (("data"->>'price')::numeric <= $2)`,
+			vals:     []interface{}{9, Decimal("10.5")},
+		},
+		{
+			query:    `active=true -seen>"2019-03-02"`,
+			expected: `(("data"->'flags'->>'active')::boolean = $1) AND (("data"->>'seen')::timestamptz IS NULL OR NOT ("data"->>'seen')::timestamptz > $2)`,
+			vals:     []interface{}{true, time.Date(2019, time.March, 2, 0, 0, 0, 0, time.UTC)},
+		},
+	}
+	for i, test := range tests {
+		sql, vals, err := filters.ToSQL(test.query, WithDialect(PostgreSQL))
+		require.NoError(t, err)
+
+		require.Equal(t, test.expected, sql, "test %v: [%v]", i, test.query)
+		require.Len(t, vals, len(test.vals))
+		for j, val := range vals {
+			require.EqualValues(t, test.vals[j], val, "test %v, value %v: [%v]", i, j, test.query)
+		}
+	}
+}
+
+func TestToSQL(t *testing.T) {
+	filters := Filters{
+		IDParam("id"),
+		StringParam("str"),
+	}
+
+	sql, vals, err := filters.ToSQL(`id=3 str:foo`)
+	require.NoError(t, err)
+	require.Equal(t, `(id = ?) AND (str LIKE ?)`, sql)
+	require.EqualValues(t, []interface{}{int64(3), "%foo%"}, vals)
+
+	sql, vals, err = filters.ToSQL(`id=3 str:foo`, WithDialect(PostgreSQL), WithPlaceholderStart(3))
+	require.NoError(t, err)
+	require.Equal(t, `("id" = $3) AND ("str" ILIKE $4)`, sql)
+	require.Len(t, vals, 2)
+
+	sql, vals, err = filters.ToSQL(``)
+	require.NoError(t, err)
+	require.Empty(t, sql)
+	require.Empty(t, vals)
+}
+
+func TestMatcherOrderingCoercion(t *testing.T) {
+	filters := Filters{
+		TimestampParam("ts"),
+		IntParam("stock"),
+		FloatParam("rating"),
+		StringParam("name", Operators(parse.OpGreaterThan, parse.OpLessOrEqualThan)),
+	}
+
+	matcher, err := filters.Matcher(`ts>"2020-01-01" ts<="2020-01-02T10:00:00Z"`)
+	require.NoError(t, err)
+
+	madrid := time.FixedZone("Europe/Madrid", 3600)
+	require.True(t, matcher(map[string]interface{}{"ts": time.Date(2020, time.January, 1, 0, 0, 1, 0, time.UTC)}))
+	require.True(t, matcher(map[string]interface{}{"ts": time.Date(2020, time.January, 2, 11, 0, 0, 0, madrid)}))
+	require.False(t, matcher(map[string]interface{}{"ts": time.Date(2020, time.January, 1, 0, 30, 0, 0, madrid)}))
+	require.False(t, matcher(map[string]interface{}{"ts": "2020-01-01T10:00:00Z"}))
+
+	matcher, err = filters.Matcher(`stock>=3 rating<4`)
+	require.NoError(t, err)
+
+	require.True(t, matcher(map[string]interface{}{"stock": 3, "rating": float32(3.5)}))
+	require.True(t, matcher(map[string]interface{}{"stock": int32(4), "rating": 2}))
+	require.True(t, matcher(map[string]interface{}{"stock": uint8(5), "rating": int64(3)}))
+	require.True(t, matcher(map[string]interface{}{"stock": 3.5, "rating": 3.9}))
+	require.False(t, matcher(map[string]interface{}{"stock": 2, "rating": 3.5}))
+	require.False(t, matcher(map[string]interface{}{"stock": "5", "rating": 3.5}))
+
+	matcher, err = filters.Matcher(`name>"b" name<=d`)
+	require.NoError(t, err)
+
+	require.True(t, matcher(map[string]interface{}{"name": "c"}))
+	require.True(t, matcher(map[string]interface{}{"name": "d"}))
+	require.False(t, matcher(map[string]interface{}{"name": "b"}))
+	require.False(t, matcher(map[string]interface{}{"name": "da"}))
+}
+
+type customStatus string
+
+func TestMatcherCoercion(t *testing.T) {
+	filters := Filters{
+		IDParam("id"),
+		StringParam("name"),
+		EnumParam("enum", pb.FooEnum_value),
+		BoolParam("active"),
+		TimestampParam("ts", Operators(parse.OpEqual, parse.OpExists)),
+	}
+
+	matcher, err := filters.Matcher(`id=3 name=foo enum=FOOENUM_FIRST active=true ts="2020-01-01T10:00:00Z"`)
+	require.NoError(t, err)
+
+	name := "foo"
+	enum := pb.FooEnum_FOOENUM_FIRST
+	active := true
+	ts := time.Date(2020, time.January, 1, 11, 0, 0, 0, time.FixedZone("Europe/Madrid", 3600))
+	require.True(t, matcher(map[string]interface{}{
+		"id":     3,
+		"name":   &name,
+		"enum":   &enum,
+		"active": &active,
+		"ts":     &ts,
+	}))
+	require.True(t, matcher(map[string]interface{}{
+		"id":     uint32(3),
+		"name":   customStatus("foo"),
+		"enum":   enum,
+		"active": true,
+		"ts":     ts.UTC(),
+	}))
+
+	var nilName *string
+	matcher, err = filters.Matcher(`-ts:* name!=foo enum!=FOOENUM_FIRST`)
+	require.NoError(t, err)
+	require.True(t, matcher(map[string]interface{}{"ts": (*time.Time)(nil), "name": nilName, "enum": (*pb.FooEnum)(nil)}))
+	require.True(t, matcher(map[string]interface{}{"ts": nil}))
+	require.False(t, matcher(map[string]interface{}{"ts": &ts}))
+}
+
+func TestMatcherE(t *testing.T) {
+	filters := Filters{
+		IDParam("id"),
+		StringParam("name"),
+	}
+
+	matcher, err := filters.MatcherE(`id=3 OR name:foo`)
+	require.NoError(t, err)
+
+	result, err := matcher(map[string]interface{}{"id": int64(3), "name": 42})
+	require.NoError(t, err)
+	require.True(t, result)
+
+	result, err = matcher(map[string]interface{}{"id": int64(4), "name": 42})
+	require.False(t, result)
+	require.EqualError(t, err, "cannot match value of type int in field name")
+	merr, ok := errors.Cause(err).(*MatchError)
+	require.True(t, ok)
+	require.Equal(t, "name", merr.Field)
+	require.Equal(t, 42, merr.Value)
+
+	result, err = matcher(map[string]interface{}{"id": "3"})
+	require.False(t, result)
+	require.Error(t, err)
+
+	simple, err := filters.Matcher(`name:foo`)
+	require.NoError(t, err)
+	require.NotPanics(t, func() {
+		require.False(t, simple(map[string]interface{}{"name": 42}))
+	})
+}
+
+type structAuthor struct {
+	Name string
+}
+
+type structBase struct {
+	ID int64
+}
+
+type structPost struct {
+	structBase
+	Title      string
+	HTTPStatus int32
+	Published  bool `expr:"live"`
+	CreatedAt  time.Time
+	Author     *structAuthor
+	Status     pb.FooEnum
+	Internal   string `expr:"-"`
+}
+
+func TestStructMatcher(t *testing.T) {
+	filters := Filters{
+		IDParam("id"),
+		StringParam("title"),
+		IntParam("httpStatus"),
+		BoolParam("live"),
+		TimestampParam("createdAt"),
+		StringParam("author.name"),
+		EnumParam("status", pb.FooEnum_value),
+	}
+
+	post := &structPost{
+		structBase: structBase{ID: 3},
+		Title:      "foo bar",
+		HTTPStatus: 200,
+		Published:  true,
+		CreatedAt:  time.Date(2019, time.January, 1, 0, 0, 0, 0, time.UTC),
+		Author:     &structAuthor{Name: "john"},
+		Status:     pb.FooEnum_FOOENUM_FIRST,
+	}
+
+	tests := []struct {
+		query    string
+		expected bool
+	}{
+		{`id=3`, true},
+		{`id=4`, false},
+		{`title:bar`, true},
+		{`httpStatus>=200 httpStatus<300`, true},
+		{`live=true`, true},
+		{`createdAt>"2018-01-01T00:00:00Z"`, true},
+		{`author.name="john"`, true},
+		{`author.name="mary"`, false},
+		{`status=FOOENUM_FIRST`, true},
+		{`id=4 OR status=FOOENUM_FIRST`, true},
+	}
+	for _, test := range tests {
+		matcher, err := filters.StructMatcher(test.query)
+		require.NoError(t, err, test.query)
+		require.Equal(t, test.expected, matcher(post), test.query)
+		require.Equal(t, test.expected, matcher(*post), test.query)
+	}
+
+	matcher, err := filters.StructMatcher(`author.name="john"`)
+	require.NoError(t, err)
+	require.False(t, matcher(&structPost{}))
+}
+
+func TestStructMatcherE(t *testing.T) {
+	filters := Filters{
+		StringParam("internal"),
+		StringParam("title"),
+	}
+
+	matcher, err := filters.StructMatcherE(`internal="foo"`)
+	require.NoError(t, err)
+	_, err = matcher(&structPost{Internal: "foo"})
+	require.Error(t, err)
+
+	matcher, err = filters.StructMatcherE(`title="foo"`)
+	require.NoError(t, err)
+	_, err = matcher("foo")
+	require.Error(t, err)
+	_, err = matcher((*structPost)(nil))
+	require.Error(t, err)
+}
+
+type structRecursive struct {
+	*structRecursive
+	*structRecursiveOther
+	Name string
+}
+
+type structRecursiveOther struct {
+	*structRecursive
+	Other string
+}
+
+func TestStructMatcherRecursiveEmbedding(t *testing.T) {
+	filters := Filters{
+		StringParam("name"),
+		StringParam("other"),
+	}
+
+	matcher, err := filters.StructMatcherE(`name="foo" other="bar"`)
+	require.NoError(t, err)
+
+	result, err := matcher(&structRecursive{
+		Name:                 "foo",
+		structRecursiveOther: &structRecursiveOther{Other: "bar"},
+	})
+	require.NoError(t, err)
+	require.True(t, result)
+
+	result, err = matcher(&structRecursive{Name: "foo"})
+	require.NoError(t, err)
+	require.False(t, result)
+}
+
+func TestLowerCamelName(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"ID", "id"},
+		{"UserID", "userID"},
+		{"HTTPStatus", "httpStatus"},
+		{"CreatedAt", "createdAt"},
+		{"name", "name"},
+		{"A", "a"},
+	}
+	for _, test := range tests {
+		require.Equal(t, test.expected, lowerCamelName(test.name), test.name)
+	}
+}
+
+func TestProtoMatcher(t *testing.T) {
+	filters := Filters{
+		IDParam("id"),
+		StringParam("displayName"),
+		EnumParam("status", pb.FooEnum_value),
+		BoolParam("enabled"),
+		FloatParam("rating"),
+		TimestampParam("createTime"),
+		StringParam("nickname"),
+		StringParam("tags", Operators(parse.OpEqual, parse.OpNotEqual, parse.OpExists)),
+		StringParam("bar.name"),
+		IntParam("bars.count"),
+		StringParam("url"),
+		IntParam("code", Operators(parse.OpEqual, parse.OpExists)),
+	}
+
+	msg := &pb.Foo{
+		Id:          3,
+		DisplayName: "foo bar",
+		Status:      pb.FooEnum_FOOENUM_FIRST,
+		Rating:      4.5,
+		CreateTime:  timestamppb.New(time.Date(2019, time.January, 1, 0, 0, 0, 0, time.UTC)),
+		Nickname:    wrapperspb.String("baz"),
+		Tags:        []string{"red", "green"},
+		Bar:         &pb.Bar{Name: "qux"},
+		Bars:        []*pb.Bar{{Count: 1}, {Count: 5}},
+		Source:      &pb.Foo_Url{Url: "https://example.com/"},
+	}
+
+	tests := []struct {
+		query    string
+		expected bool
+	}{
+		{`id=3`, true},
+		{`id=4`, false},
+		{`displayName:bar`, true},
+		{`status=FOOENUM_FIRST`, true},
+		{`status=FOOENUM_SECOND`, false},
+		{`enabled=false`, true},
+		{`rating>4`, true},
+		{`createTime>"2018-01-01T00:00:00Z"`, true},
+		{`createTime<"2018-01-01T00:00:00Z"`, false},
+		{`createTime:*`, true},
+		{`nickname="baz"`, true},
+		{`tags="green"`, true},
+		{`tags="blue"`, false},
+		{`tags!="green"`, false},
+		{`tags!="blue"`, true},
+		{`tags:*`, true},
+		{`bar.name="qux"`, true},
+		{`bars.count>3`, true},
+		{`bars.count>5`, false},
+		{`url:example`, true},
+		{`code:*`, false},
+		{`code=0`, false},
+	}
+	for _, test := range tests {
+		matcher, err := filters.ProtoMatcher(test.query)
+		require.NoError(t, err, test.query)
+		require.Equal(t, test.expected, matcher(msg), test.query)
+	}
+
+	empty := new(pb.Foo)
+	for query, expected := range map[string]bool{
+		`createTime:*`:    false,
+		`nickname="baz"`:  false,
+		`nickname!="baz"`: true,
+		`tags:*`:          false,
+		`bar.name="qux"`:  false,
+		`id=0`:            true,
+	} {
+		matcher, err := filters.ProtoMatcher(query)
+		require.NoError(t, err, query)
+		require.Equal(t, expected, matcher(empty), query)
+	}
+}
+
+func TestProtoMatcherE(t *testing.T) {
+	filters := Filters{
+		StringParam("unknown"),
+		StringParam("id"),
+		StringParam("tags.foo"),
+	}
+
+	for _, query := range []string{`unknown="foo"`, `id="foo"`, `tags.foo="bar"`} {
+		matcher, err := filters.ProtoMatcherE(query)
+		require.NoError(t, err)
+		_, err = matcher(&pb.Foo{Id: 3, Tags: []string{"foo"}})
+		require.Error(t, err, query)
+	}
+
+	matcher, err := filters.ProtoMatcherE(`id="foo"`)
+	require.NoError(t, err)
+	_, err = matcher(nil)
+	require.Error(t, err)
+}
+
+func TestProtoFilters(t *testing.T) {
+	filters := ProtoFilters(new(pb.Foo))
+
+	var names []string
+	for _, f := range filters {
+		names = append(names, f.Name())
+	}
+	require.Equal(t, []string{"id", "displayName", "status", "enabled", "rating", "createTime", "nickname", "url", "code"}, names)
+
+	sql, vals, err := filters.ToSQL(`id=3 status=FOOENUM_FIRST enabled=true createTime>"2019-01-01T00:00:00Z" nickname:foo code>2`)
+	require.NoError(t, err)
+	require.Equal(t, "(id = ?) AND (status = ?) AND (enabled = ?) AND (create_time > ?) AND (nickname LIKE ?) AND (code > ?)", sql)
+	require.Len(t, vals, 6)
+
+	_, _, err = filters.ToSQL(`status=FOOENUM_OTHER`)
+	require.Error(t, err)
+	_, _, err = filters.ToSQL(`id=-1`)
+	require.Error(t, err)
+
+	matcher, err := filters.ProtoMatcher(`displayName:bar rating>=4`)
+	require.NoError(t, err)
+	require.True(t, matcher(&pb.Foo{DisplayName: "foo bar", Rating: 4}))
+}
+
+func TestProtoFiltersOptions(t *testing.T) {
+	filters := ProtoFilters(new(pb.Foo),
+		IncludeFields("id", "display_name", "status", "code"),
+		ExcludeFields("code"),
+		OverrideParam(StringParam("status")),
+		FieldOptions("displayName", Required()),
+		FieldOptions("display_name", Alias("name")),
+		FieldOptions("displayName", Alias("title")),
+	)
+
+	var names []string
+	for _, f := range filters {
+		names = append(names, f.Name())
+	}
+	require.Equal(t, []string{"id", "displayName", "status"}, names)
+	require.Equal(t, []string{"name", "title"}, filters[1].Aliases())
+
+	_, _, err := filters.ToSQL(`id=3`)
+	require.Error(t, err)
+
+	sql, _, err := filters.ToSQL(`displayName="foo" status="bar"`)
+	require.NoError(t, err)
+	require.Equal(t, "(display_name = ?) AND (status = ?)", sql)
+
+	require.Panics(t, func() {
+		ProtoFilters(new(pb.Foo), ExcludeFields("unknown"))
+	})
+}
+
+func TestProtoFiltersAnnotations(t *testing.T) {
+	filters := ProtoFilters(new(pb.Annotated))
+
+	var names []string
+	for _, f := range filters {
+		names = append(names, f.Name())
+	}
+	require.Equal(t, []string{"id", "name", "count"}, names)
+	require.Equal(t, []string{"title"}, filters[1].Aliases())
+	require.Equal(t, "Nombre", filters[1].Description())
+
+	_, _, err := filters.ToSQL(`name="foo"`)
+	require.Error(t, err)
+
+	sql, _, err := filters.ToSQL(`id=3 title:foo count>2`)
+	require.NoError(t, err)
+	require.Equal(t, "(id = ?) AND (name LIKE ?) AND (stats.count > ?)", sql)
+
+	_, _, err = filters.ToSQL(`id=3 count<2`)
+	require.Error(t, err)
+
+	filters = ProtoFilters(new(pb.Annotated), IncludeFields("internal"))
+	require.Len(t, filters, 1)
+	require.Equal(t, "internal", filters[0].Name())
+}
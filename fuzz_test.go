@@ -0,0 +1,97 @@
+//go:build go1.18
+// +build go1.18
+
+package expr
+
+import (
+	"testing"
+	"time"
+
+	"github.com/altipla-consulting/expr/parse"
+	pb "github.com/altipla-consulting/expr/testdata/foo"
+)
+
+func FuzzFilters(f *testing.F) {
+	for _, query := range []string{
+		``,
+		`id=3`,
+		`id!=4 enum=FOOENUM_FIRST`,
+		`-id=3`,
+		`-ts:*`,
+		`boolUppercase=TRUE`,
+		`ts>"2019-03-02"`,
+		`ts>"2019-03-02T14:15:16Z"`,
+		`str=foo`,
+		`str:"foo%bar"`,
+		`id=3 OR id=4 enum=FOOENUM_FIRST`,
+		`(id=3 enum=FOOENUM_FIRST) OR str:foo`,
+		`NOT (id=3 OR id=4) AND enum=FOOENUM_FIRST`,
+		`-enum IN (FOOENUM_FIRST, FOOENUM_SECOND) str IN (foo)`,
+		`rating>=4.5 rating<5`,
+		`price<=10.50 price>1.25e1 price!=+3`,
+		`stock>-5 stock<=10 stock IN (1, 2)`,
+		`enum=FOOENUM_UNKNOWN`,
+		`id=-1`,
+		`ts>"not a date"`,
+		`price=1e-9999999`,
+		`price=1e-999999`,
+	} {
+		f.Add(query)
+	}
+
+	filters := Filters{
+		IDParam("id"),
+		EnumParam("enum", pb.FooEnum_value),
+		TimestampParam("ts"),
+		BoolParam("boolUppercase"),
+		StringParam("str"),
+		FloatParam("rating"),
+		DecimalParam("price"),
+		IntParam("stock", Min(-10), Max(10)),
+	}
+	data := map[string]interface{}{
+		"id":            int64(3),
+		"enum":          pb.FooEnum_FOOENUM_FIRST,
+		"ts":            time.Date(2019, time.March, 2, 14, 15, 16, 0, time.UTC),
+		"boolUppercase": true,
+		"str":           "foo%bar",
+		"rating":        4.5,
+		"price":         Decimal("10.50"),
+		"stock":         int32(3),
+	}
+
+	f.Fuzz(func(t *testing.T, query string) {
+		root, _, err := filters.parseQuery(query)
+		if err != nil {
+			return
+		}
+
+		for _, dialect := range []Dialect{MySQL, PostgreSQL, SQLite} {
+			if _, _, err := filters.ToSQL(query, WithDialect(dialect)); err != nil {
+				t.Fatalf("valid query cannot be converted to SQL: %v", err)
+			}
+		}
+
+		matcher, err := filters.MatcherE(query)
+		if err != nil {
+			t.Fatalf("valid query cannot be matched: %v", err)
+		}
+		result, err := matcher(data)
+		if err != nil {
+			t.Fatalf("cannot match query: %v", err)
+		}
+
+		// La consulta formateada tiene que ser equivalente a la original.
+		formatted := parse.Format(root)
+		if err := filters.Validate(root); err != nil {
+			t.Fatalf("parsed tree does not validate: %v", err)
+		}
+		again, err := filters.MatcherE(formatted)
+		if err != nil {
+			t.Fatalf("formatted query %q is not valid: %v", formatted, err)
+		}
+		if r, err := again(data); err != nil || r != result {
+			t.Fatalf("formatted query %q matches differently: %v != %v (%v)", formatted, r, result, err)
+		}
+	})
+}
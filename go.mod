@@ -3,8 +3,10 @@ module github.com/altipla-consulting/expr
 go 1.13
 
 require (
-	github.com/golang/protobuf v1.3.3
+	github.com/golang/protobuf v1.5.2
+	github.com/mattn/go-sqlite3 v1.14.16
 	github.com/stretchr/testify v1.4.0
 	google.golang.org/grpc v1.28.1
+	google.golang.org/protobuf v1.27.1
 	libs.altipla.consulting v1.62.0
 )
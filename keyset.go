@@ -0,0 +1,95 @@
+package expr
+
+import (
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"libs.altipla.consulting/errors"
+
+	"github.com/altipla-consulting/expr/parse"
+)
+
+type keyset struct {
+	ordering Ordering
+	cursor   []interface{}
+}
+
+// WithKeyset añade a la consulta la condición para leer los elementos que van
+// después del cursor en el orden indicado. El cursor son los valores de los
+// campos del orden en el último elemento de la página anterior, normalmente
+// leídos de un token con PageTokens.Decode. El orden debería terminar en un
+// campo único para que no se repitan ni se pierdan elementos entre páginas.
+//
+// Las columnas del orden tienen que ser NOT NULL: la condición no tiene en
+// cuenta los valores nulos, que cada motor ordena en un sitio distinto, y las
+// filas con nulos se perderían. Si el cursor tiene algún valor nulo la consulta
+// devuelve un error InvalidArgument.
+func WithKeyset(ordering Ordering, cursor []interface{}) SQLOption {
+	return func(opts *sqlOptions) {
+		opts.keyset = &keyset{
+			ordering: ordering,
+			cursor:   cursor,
+		}
+	}
+}
+
+// evalKeyset compara las filas con el cursor. Si todos los campos van en la
+// misma dirección y el motor lo permite se usa una comparación de filas
+// (a, b) > (?, ?); si no se expande a (a > ?) OR (a = ? AND b > ?).
+func (e *sqlEvaluator) evalKeyset(ks *keyset) (string, error) {
+	if len(ks.ordering) == 0 {
+		return "", errors.Errorf("keyset pagination requires an ordering")
+	}
+	if len(ks.cursor) != len(ks.ordering) {
+		return "", errors.Errorf("keyset pagination requires %d cursor values, got %d", len(ks.ordering), len(ks.cursor))
+	}
+
+	sameDirection := true
+	columns := make([]string, len(ks.ordering))
+	for i, term := range ks.ordering {
+		if ks.cursor[i] == nil {
+			return "", status.Errorf(codes.InvalidArgument, "keyset pagination does not support null values: %v", term.Field)
+		}
+		if term.Desc != ks.ordering[0].Desc {
+			sameDirection = false
+		}
+		column, err := term.sqlColumn(e.dialect)
+		if err != nil {
+			return "", errors.Trace(err)
+		}
+		columns[i] = column
+	}
+
+	if len(columns) == 1 {
+		return "(" + e.dialect.compare(columns[0], keysetOperator(ks.ordering[0]), false, e.placeholder(e.dialect.value(ks.cursor[0]))) + ")", nil
+	}
+
+	if sameDirection && e.dialect.rowValues() {
+		placeholders := make([]string, len(ks.cursor))
+		for i, value := range ks.cursor {
+			placeholders[i] = e.placeholder(e.dialect.value(value))
+		}
+		left := "(" + strings.Join(columns, ", ") + ")"
+		right := "(" + strings.Join(placeholders, ", ") + ")"
+		return e.dialect.compare(left, keysetOperator(ks.ordering[0]), false, right), nil
+	}
+
+	alternatives := make([]string, len(ks.ordering))
+	for i, term := range ks.ordering {
+		var conds []string
+		for j := 0; j < i; j++ {
+			conds = append(conds, e.dialect.compare(columns[j], parse.OpEqual, false, e.placeholder(e.dialect.value(ks.cursor[j]))))
+		}
+		conds = append(conds, e.dialect.compare(columns[i], keysetOperator(term), false, e.placeholder(e.dialect.value(ks.cursor[i]))))
+		alternatives[i] = "(" + strings.Join(conds, " AND ") + ")"
+	}
+	return "(" + strings.Join(alternatives, " OR ") + ")", nil
+}
+
+func keysetOperator(term OrderTerm) parse.Operator {
+	if term.Desc {
+		return parse.OpLessThan
+	}
+	return parse.OpGreaterThan
+}
@@ -0,0 +1,123 @@
+package expr
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"libs.altipla.consulting/database"
+)
+
+// expandedKeysetDialect simula un motor que no sabe comparar filas para probar
+// la condición expandida aunque todos los campos vayan en la misma dirección.
+type expandedKeysetDialect struct {
+	Dialect
+}
+
+func (expandedKeysetDialect) rowValues() bool {
+	return false
+}
+
+func TestKeysetSQL(t *testing.T) {
+	filters := Filters{
+		StringParam("name"),
+	}
+
+	tests := []struct {
+		orderBy  string
+		cursor   []interface{}
+		query    string
+		dialect  Dialect
+		expected string
+		vals     []interface{}
+	}{
+		{
+			orderBy:  "name",
+			cursor:   []interface{}{"foo"},
+			expected: "(name > ?)",
+			vals:     []interface{}{"foo"},
+		},
+		{
+			orderBy:  "createTime desc",
+			cursor:   []interface{}{"foo"},
+			query:    "name:bar",
+			expected: "(name LIKE ?) AND (create_time < ?)",
+			vals:     []interface{}{"%bar%", "foo"},
+		},
+		{
+			orderBy:  "createTime, name",
+			cursor:   []interface{}{"2019", "foo"},
+			query:    "name:bar",
+			expected: "(name LIKE ?) AND (create_time, name) > (?, ?)",
+			vals:     []interface{}{"%bar%", "2019", "foo"},
+		},
+		{
+			orderBy:  "createTime desc, name desc",
+			cursor:   []interface{}{"2019", "foo"},
+			dialect:  PostgreSQL,
+			expected: `("create_time", "name") < ($1, $2)`,
+			vals:     []interface{}{"2019", "foo"},
+		},
+		{
+			orderBy:  "createTime, name",
+			cursor:   []interface{}{"2019", "foo"},
+			dialect:  expandedKeysetDialect{MySQL},
+			expected: "((create_time > ?) OR (create_time = ? AND name > ?))",
+			vals:     []interface{}{"2019", "2019", "foo"},
+		},
+		{
+			orderBy:  "createTime desc, name desc",
+			cursor:   []interface{}{"2019", "foo"},
+			query:    "name:bar",
+			dialect:  expandedKeysetDialect{PostgreSQL},
+			expected: `("name" ILIKE $1) AND (("create_time" < $2) OR ("create_time" = $3 AND "name" < $4))`,
+			vals:     []interface{}{"%bar%", "2019", "2019", "foo"},
+		},
+		{
+			orderBy:  "createTime desc, name, rating",
+			cursor:   []interface{}{"2019", "foo", int64(3)},
+			query:    "name:bar",
+			dialect:  PostgreSQL,
+			expected: `("name" ILIKE $1) AND (("create_time" < $2) OR ("create_time" = $3 AND "name" > $4) OR ("create_time" = $5 AND "name" = $6 AND "stats"."rating" > $7))`,
+			vals:     []interface{}{"%bar%", "2019", "2019", "foo", "2019", "foo", int64(3)},
+		},
+	}
+	for _, test := range tests {
+		ordering, err := testOrderFields.Parse(test.orderBy)
+		require.NoError(t, err)
+
+		dialect := test.dialect
+		if dialect == nil {
+			dialect = MySQL
+		}
+		sql, vals, err := filters.ToSQL(test.query, WithDialect(dialect), WithKeyset(ordering, test.cursor))
+		require.NoError(t, err, test.orderBy)
+		require.Equal(t, test.expected, sql, test.orderBy)
+		require.Equal(t, test.vals, vals, test.orderBy)
+	}
+}
+
+func TestKeysetErrors(t *testing.T) {
+	ordering, err := testOrderFields.Parse("name, rating")
+	require.NoError(t, err)
+
+	_, _, err = Filters{}.ToSQL("", WithKeyset(ordering, []interface{}{"foo"}))
+	require.Error(t, err)
+
+	_, _, err = Filters{}.ToSQL("", WithKeyset(ordering, []interface{}{"foo", nil}))
+	require.Error(t, err)
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, _, err = Filters{}.ToSQL("", WithKeyset(nil, nil))
+	require.Error(t, err)
+}
+
+func TestKeysetApplySQL(t *testing.T) {
+	ordering, err := testOrderFields.Parse("name")
+	require.NoError(t, err)
+
+	q, err := Filters{}.ApplySQL(new(database.Collection), "", WithKeyset(ordering, []interface{}{"foo"}))
+	require.NoError(t, err)
+	require.NotNil(t, q)
+}
@@ -0,0 +1,329 @@
+package expr
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"libs.altipla.consulting/database"
+	"libs.altipla.consulting/errors"
+)
+
+// OrderField es un campo por el que se puede ordenar el resultado con order_by.
+type OrderField struct {
+	name   string
+	column column
+}
+
+// OrderParam declara un campo de ordenación. Acepta las mismas opciones de
+// columna que los filtros (Column, JSONColumn y ColumnExpr); cualquier otra
+// opción es un fallo de programación y entra en pánico.
+func OrderParam(name string, opts ...ParamOption) *OrderField {
+	f := new(Filter)
+	for _, opt := range opts {
+		opt(f)
+	}
+	c := f.column
+	f.column = column{}
+	if !reflect.DeepEqual(f, new(Filter)) {
+		panic(fmt.Sprintf("only column options are allowed in order field: %v", name))
+	}
+	return &OrderField{name: name, column: c}
+}
+
+func (f *OrderField) Name() string {
+	return f.name
+}
+
+func (f *OrderField) sqlColumn(dialect Dialect) string {
+	if !f.column.isZero() {
+		return dialect.column(f.column)
+	}
+	return dialect.column(parseColumn(sqlizeName(f.name)))
+}
+
+type OrderFields []*OrderField
+
+// Parse lee un order_by como "createTime desc, name" siguiendo la AIP-132.
+func (fs OrderFields) Parse(orderBy string) (Ordering, error) {
+	if strings.TrimSpace(orderBy) == "" {
+		return nil, nil
+	}
+
+	fields := make(map[string]*OrderField)
+	for _, f := range fs {
+		fields[f.name] = f
+	}
+
+	var ordering Ordering
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(orderBy, ",") {
+		words := strings.Fields(part)
+		var desc bool
+		switch len(words) {
+		case 1:
+		case 2:
+			switch strings.ToLower(words[1]) {
+			case "asc":
+			case "desc":
+				desc = true
+			default:
+				return nil, status.Errorf(codes.InvalidArgument, "invalid order direction: %v: %s", words[0], words[1])
+			}
+		default:
+			return nil, status.Errorf(codes.InvalidArgument, "invalid order expression: %q", strings.TrimSpace(part))
+		}
+
+		f, ok := fields[words[0]]
+		if !ok {
+			return nil, status.Errorf(codes.InvalidArgument, "order not allowed for field: %v", words[0])
+		}
+		if seen[f.name] {
+			return nil, status.Errorf(codes.InvalidArgument, "duplicated order field: %v", f.name)
+		}
+		seen[f.name] = true
+
+		ordering = append(ordering, OrderTerm{Field: f.name, Desc: desc, field: f})
+	}
+
+	return ordering, nil
+}
+
+func (fs OrderFields) ApplySQL(q *database.Collection, orderBy string) (*database.Collection, error) {
+	ordering, err := fs.Parse(orderBy)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return ordering.ApplySQL(q)
+}
+
+type OrderTerm struct {
+	Field string
+	Desc  bool
+
+	field *OrderField
+}
+
+func (term OrderTerm) String() string {
+	if term.Desc {
+		return term.Field + " desc"
+	}
+	return term.Field
+}
+
+// sqlColumn devuelve la columna del campo. Solo los términos leídos con
+// OrderFields.Parse la conocen; los construidos a mano no se pueden llevar a
+// SQL porque el nombre no se ha comprobado.
+func (term OrderTerm) sqlColumn(dialect Dialect) (string, error) {
+	if term.field == nil {
+		return "", errors.Errorf("order term not parsed with OrderFields.Parse: %v", term.Field)
+	}
+	return term.field.sqlColumn(dialect), nil
+}
+
+// Ordering es el resultado de leer un order_by con los campos en orden de
+// prioridad.
+type Ordering []OrderTerm
+
+// String devuelve el order_by normalizado.
+func (o Ordering) String() string {
+	terms := make([]string, len(o))
+	for i, term := range o {
+		terms[i] = term.String()
+	}
+	return strings.Join(terms, ", ")
+}
+
+func (o Ordering) ApplySQL(q *database.Collection) (*database.Collection, error) {
+	for _, term := range o {
+		column, err := term.sqlColumn(MySQL)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if term.Desc {
+			column = "-" + column
+		}
+		q = q.Order(column)
+	}
+	return q, nil
+}
+
+// ToSQL devuelve el contenido del ORDER BY de la consulta.
+func (o Ordering) ToSQL(opts ...SQLOption) (string, error) {
+	options := &sqlOptions{
+		dialect: MySQL,
+	}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	terms := make([]string, len(o))
+	for i, term := range o {
+		column, err := term.sqlColumn(options.dialect)
+		if err != nil {
+			return "", errors.Trace(err)
+		}
+		terms[i] = column
+		if term.Desc {
+			terms[i] += " DESC"
+		}
+	}
+	return strings.Join(terms, ", "), nil
+}
+
+// Values devuelve los valores de los campos del orden en un mapa, struct o
+// mensaje protobuf, por ejemplo para generar el token de la siguiente página.
+func (o Ordering) Values(item interface{}) ([]interface{}, error) {
+	lookup, err := dataLookup(item)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return o.lookupValues(lookup)
+}
+
+func (o Ordering) lookupValues(lookup valueLookup) ([]interface{}, error) {
+	values := make([]interface{}, len(o))
+	for i, term := range o {
+		value, err := lookup(term.Field)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if _, ok := value.(repeatedValue); ok {
+			return nil, errors.Errorf("cannot sort by repeated field: %v", term.Field)
+		}
+		values[i] = sortValue(value)
+	}
+	return values, nil
+}
+
+// Sort ordena en memoria un slice de mapas, structs o mensajes protobuf. Los
+// valores nulos van primero en orden ascendente igual que en SQL.
+func (o Ordering) Sort(slice interface{}) error {
+	rv := reflect.ValueOf(slice)
+	if rv.Kind() != reflect.Slice {
+		return errors.Errorf("cannot sort value of type %T, expected a slice", slice)
+	}
+
+	s := &sortableSlice{
+		ordering: o,
+		swap:     reflect.Swapper(slice),
+		keys:     make([][]interface{}, rv.Len()),
+	}
+	for i := range s.keys {
+		lookup, err := dataLookup(rv.Index(i).Interface())
+		if err != nil {
+			return errors.Trace(err)
+		}
+		s.keys[i], err = o.lookupValues(lookup)
+		if err != nil {
+			return errors.Trace(err)
+		}
+	}
+	sort.Stable(s)
+
+	return errors.Trace(s.err)
+}
+
+type sortableSlice struct {
+	ordering Ordering
+	swap     func(i, j int)
+	keys     [][]interface{}
+	err      error
+}
+
+func (s *sortableSlice) Len() int {
+	return len(s.keys)
+}
+
+func (s *sortableSlice) Swap(i, j int) {
+	s.swap(i, j)
+	s.keys[i], s.keys[j] = s.keys[j], s.keys[i]
+}
+
+func (s *sortableSlice) Less(i, j int) bool {
+	for t, term := range s.ordering {
+		cmp, ok := compareSortValues(s.keys[i][t], s.keys[j][t])
+		if !ok {
+			if s.err == nil {
+				s.err = &MatchError{Field: term.Field, Value: s.keys[j][t]}
+			}
+			return false
+		}
+		if cmp == 0 {
+			continue
+		}
+		if term.Desc {
+			return cmp > 0
+		}
+		return cmp < 0
+	}
+	return false
+}
+
+// sortValue convierte el valor del dato a uno de los tipos que devuelven los
+// filtros para poder compararlos entre ellos.
+func sortValue(v interface{}) interface{} {
+	v = normalizeValue(v)
+	switch v.(type) {
+	case nil, string, bool, time.Time, Decimal:
+		return v
+	}
+	if n, ok := toInt64(v); ok {
+		return n
+	}
+	if n, ok := toFloat64(v); ok {
+		return n
+	}
+	return v
+}
+
+func compareSortValues(a, b interface{}) (int, bool) {
+	switch {
+	case a == nil && b == nil:
+		return 0, true
+	case a == nil:
+		return -1, true
+	case b == nil:
+		return 1, true
+	}
+
+	// compareValues compara el segundo valor respecto al primero.
+	cmp, ok := compareValues(b, a)
+	return cmp, ok
+}
+
+// dataLookup busca los valores en un mapa, un struct o un mensaje protobuf
+// igual que los matchers de cada uno de ellos.
+func dataLookup(value interface{}) (valueLookup, error) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return func(name string) (interface{}, error) {
+			return v[name], nil
+		}, nil
+
+	case proto.Message:
+		m := proto.MessageReflect(v)
+		return func(name string) (interface{}, error) {
+			return lookupProtoField(m, strings.Split(name, "."), name)
+		}, nil
+	}
+
+	rv := reflect.ValueOf(value)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil, errors.Errorf("cannot read fields of nil value")
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, errors.Errorf("cannot read fields of value of type %T", value)
+	}
+	return func(name string) (interface{}, error) {
+		return lookupStructField(rv, name)
+	}, nil
+}
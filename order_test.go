@@ -0,0 +1,156 @@
+package expr
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"libs.altipla.consulting/database"
+
+	pb "github.com/altipla-consulting/expr/testdata/foo"
+)
+
+var testOrderFields = OrderFields{
+	OrderParam("createTime"),
+	OrderParam("name"),
+	OrderParam("rating", Column("stats.rating")),
+}
+
+func TestOrderingParse(t *testing.T) {
+	tests := []struct {
+		orderBy  string
+		expected string
+		sql      string
+	}{
+		{"", "", ""},
+		{"name", "name", "name"},
+		{"createTime desc, name", "createTime desc, name", "create_time DESC, name"},
+		{"  createTime   DESC ,name asc ", "createTime desc, name", "create_time DESC, name"},
+		{"rating desc", "rating desc", "stats.rating DESC"},
+	}
+	for _, test := range tests {
+		ordering, err := testOrderFields.Parse(test.orderBy)
+		require.NoError(t, err, test.orderBy)
+		require.Equal(t, test.expected, ordering.String(), test.orderBy)
+		sql, err := ordering.ToSQL()
+		require.NoError(t, err, test.orderBy)
+		require.Equal(t, test.sql, sql, test.orderBy)
+	}
+
+	ordering, err := testOrderFields.Parse("createTime desc, rating")
+	require.NoError(t, err)
+	sql, err := ordering.ToSQL(WithDialect(PostgreSQL))
+	require.NoError(t, err)
+	require.Equal(t, `"create_time" DESC, "stats"."rating"`, sql)
+}
+
+func TestOrderParamColumns(t *testing.T) {
+	fields := OrderFields{
+		OrderParam("city", JSONColumn("data", "address", "city")),
+		OrderParam("score", ColumnExpr("COALESCE(score, 0)")),
+	}
+	ordering, err := fields.Parse("city, score desc")
+	require.NoError(t, err)
+
+	sql, err := ordering.ToSQL(WithDialect(PostgreSQL))
+	require.NoError(t, err)
+	require.Equal(t, `"data"->'address'->>'city', COALESCE(score, 0) DESC`, sql)
+
+	require.Panics(t, func() { OrderParam("name", Required()) })
+	require.Panics(t, func() { OrderParam("name", Column("name"), Alias("title")) })
+}
+
+func TestOrderingUnparsedTerms(t *testing.T) {
+	ordering := Ordering{{Field: "name"}}
+
+	_, err := ordering.ToSQL()
+	require.Error(t, err)
+
+	_, err = ordering.ApplySQL(new(database.Collection))
+	require.Error(t, err)
+
+	_, _, err = Filters{}.ToSQL("", WithKeyset(ordering, []interface{}{"foo"}))
+	require.Error(t, err)
+}
+
+func TestOrderingParseErrors(t *testing.T) {
+	tests := []string{
+		"foo",
+		"name up",
+		"name desc desc",
+		"name,",
+		"name, name desc",
+	}
+	for _, test := range tests {
+		_, err := testOrderFields.Parse(test)
+		require.Error(t, err, test)
+	}
+}
+
+func TestOrderingApplySQL(t *testing.T) {
+	q, err := testOrderFields.ApplySQL(new(database.Collection), "createTime desc, name")
+	require.NoError(t, err)
+	require.NotNil(t, q)
+
+	_, err = testOrderFields.ApplySQL(new(database.Collection), "foo")
+	require.Error(t, err)
+}
+
+func TestOrderingSort(t *testing.T) {
+	ordering, err := testOrderFields.Parse("rating desc, name")
+	require.NoError(t, err)
+
+	items := []map[string]interface{}{
+		{"name": "b", "rating": 3},
+		{"name": "c", "rating": 4.5},
+		{"name": "a", "rating": 3},
+		{"name": "d"},
+	}
+	require.NoError(t, ordering.Sort(items))
+
+	var names []interface{}
+	for _, item := range items {
+		names = append(names, item["name"])
+	}
+	require.Equal(t, []interface{}{"c", "a", "b", "d"}, names)
+}
+
+func TestOrderingSortStructs(t *testing.T) {
+	ordering, err := OrderFields{OrderParam("createdAt"), OrderParam("title")}.Parse("createdAt desc, title")
+	require.NoError(t, err)
+
+	base := time.Date(2019, time.January, 1, 0, 0, 0, 0, time.UTC)
+	posts := []*structPost{
+		{Title: "b", CreatedAt: base},
+		{Title: "c", CreatedAt: base.Add(time.Hour)},
+		{Title: "a", CreatedAt: base},
+	}
+	require.NoError(t, ordering.Sort(posts))
+	require.Equal(t, "c", posts[0].Title)
+	require.Equal(t, "a", posts[1].Title)
+	require.Equal(t, "b", posts[2].Title)
+}
+
+func TestOrderingSortProto(t *testing.T) {
+	ordering, err := OrderFields{OrderParam("displayName")}.Parse("displayName desc")
+	require.NoError(t, err)
+
+	msgs := []*pb.Foo{
+		{DisplayName: "a"},
+		{DisplayName: "c"},
+		{DisplayName: "b"},
+	}
+	require.NoError(t, ordering.Sort(msgs))
+	require.Equal(t, "c", msgs[0].DisplayName)
+	require.Equal(t, "b", msgs[1].DisplayName)
+	require.Equal(t, "a", msgs[2].DisplayName)
+}
+
+func TestOrderingSortErrors(t *testing.T) {
+	ordering, err := testOrderFields.Parse("name")
+	require.NoError(t, err)
+
+	require.Error(t, ordering.Sort("foo"))
+	require.Error(t, ordering.Sort([]map[string]interface{}{{"name": "a"}, {"name": 3}}))
+	require.Error(t, ordering.Sort([]string{"a", "b"}))
+}
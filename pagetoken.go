@@ -0,0 +1,179 @@
+package expr
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"libs.altipla.consulting/errors"
+
+	"github.com/altipla-consulting/expr/parse"
+)
+
+// PageTokens genera y comprueba los tokens de paginación siguiendo la AIP-158.
+// Los tokens guardan los valores de los campos del order_by del último
+// elemento de la página y van firmados para que el cliente no los pueda
+// modificar. Un token solo es válido con el mismo filtro y orden con los que se
+// generó.
+type PageTokens struct {
+	key []byte
+}
+
+// MinPageTokenKeySize es el tamaño mínimo en bytes de la clave con la que se
+// firman los tokens. Con claves más cortas se podrían falsificar.
+const MinPageTokenKeySize = 32
+
+// NewPageTokens prepara los tokens firmados con la clave indicada. Entra en
+// pánico si la clave tiene menos de MinPageTokenKeySize bytes.
+func NewPageTokens(key []byte) *PageTokens {
+	if len(key) < MinPageTokenKeySize {
+		panic(fmt.Sprintf("page tokens key too short: %d < %d bytes", len(key), MinPageTokenKeySize))
+	}
+	return &PageTokens{key: append([]byte{}, key...)}
+}
+
+type pageToken struct {
+	Query  []byte       `json:"q"`
+	Values []tokenValue `json:"v"`
+}
+
+type tokenValue struct {
+	Type  string `json:"t"`
+	Value string `json:"v,omitempty"`
+}
+
+// Encode genera el token de la siguiente página. Los valores son los de los
+// campos del orden en el último elemento de la página; se pueden obtener con
+// Ordering.Values.
+func (pt *PageTokens) Encode(filter string, ordering Ordering, values []interface{}) (string, error) {
+	if len(values) != len(ordering) {
+		return "", errors.Errorf("page token requires %d values, got %d", len(ordering), len(values))
+	}
+
+	hash, err := pageQueryHash(filter, ordering)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	token := pageToken{
+		Query:  hash,
+		Values: make([]tokenValue, len(values)),
+	}
+	for i, value := range values {
+		tv, err := encodeTokenValue(sortValue(value))
+		if err != nil {
+			return "", errors.Trace(err)
+		}
+		token.Values[i] = tv
+	}
+
+	payload, err := json.Marshal(token)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(append(payload, pt.sign(payload)...)), nil
+}
+
+// Decode comprueba el token y devuelve los valores del cursor. Devuelve un error
+// InvalidArgument si el token no es válido o se generó con otro filtro u orden.
+func (pt *PageTokens) Decode(token, filter string, ordering Ordering) ([]interface{}, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(data) < sha256.Size {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid page token")
+	}
+	payload, signature := data[:len(data)-sha256.Size], data[len(data)-sha256.Size:]
+	if !hmac.Equal(signature, pt.sign(payload)) {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid page token")
+	}
+
+	var decoded pageToken
+	if err := json.Unmarshal(payload, &decoded); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid page token")
+	}
+
+	hash, err := pageQueryHash(filter, ordering)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if !bytes.Equal(hash, decoded.Query) || len(decoded.Values) != len(ordering) {
+		return nil, status.Errorf(codes.InvalidArgument, "page token does not match the filter and order of the request")
+	}
+
+	values := make([]interface{}, len(decoded.Values))
+	for i, tv := range decoded.Values {
+		value, err := decodeTokenValue(tv)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid page token")
+		}
+		values[i] = value
+	}
+	return values, nil
+}
+
+func (pt *PageTokens) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, pt.key)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// pageQueryHash resume el filtro y el orden de la consulta. El filtro se
+// normaliza para que los cambios de espacios, formato o del orden de los
+// términos no invaliden el token.
+func pageQueryHash(filter string, ordering Ordering) ([]byte, error) {
+	root, err := parseFilter(filter)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	h := sha256.New()
+	h.Write([]byte(parse.Format(root, parse.WithSortedTerms(), parse.WithNormalizedValues())))
+	h.Write([]byte{0})
+	h.Write([]byte(ordering.String()))
+	return h.Sum(nil), nil
+}
+
+func encodeTokenValue(value interface{}) (tokenValue, error) {
+	switch v := value.(type) {
+	case nil:
+		return tokenValue{Type: "null"}, nil
+	case int64:
+		return tokenValue{Type: "int", Value: strconv.FormatInt(v, 10)}, nil
+	case float64:
+		return tokenValue{Type: "float", Value: strconv.FormatFloat(v, 'g', -1, 64)}, nil
+	case string:
+		return tokenValue{Type: "string", Value: v}, nil
+	case bool:
+		return tokenValue{Type: "bool", Value: strconv.FormatBool(v)}, nil
+	case time.Time:
+		return tokenValue{Type: "time", Value: v.Format(time.RFC3339Nano)}, nil
+	case Decimal:
+		return tokenValue{Type: "decimal", Value: string(v)}, nil
+	}
+	return tokenValue{}, errors.Errorf("unsupported page token value of type %T", value)
+}
+
+func decodeTokenValue(tv tokenValue) (interface{}, error) {
+	switch tv.Type {
+	case "null":
+		return nil, nil
+	case "int":
+		return strconv.ParseInt(tv.Value, 10, 64)
+	case "float":
+		return strconv.ParseFloat(tv.Value, 64)
+	case "string":
+		return tv.Value, nil
+	case "bool":
+		return strconv.ParseBool(tv.Value)
+	case "time":
+		return time.Parse(time.RFC3339Nano, tv.Value)
+	case "decimal":
+		return Decimal(tv.Value), nil
+	}
+	return nil, errors.Errorf("unknown page token value type: %s", tv.Type)
+}
@@ -0,0 +1,104 @@
+package expr
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var testPageKey = []byte("0123456789abcdef0123456789abcdef")
+
+func TestPageTokensKeySize(t *testing.T) {
+	require.Panics(t, func() {
+		NewPageTokens(nil)
+	})
+	require.Panics(t, func() {
+		NewPageTokens([]byte{})
+	})
+	require.Panics(t, func() {
+		NewPageTokens(testPageKey[:31])
+	})
+	require.NotPanics(t, func() {
+		NewPageTokens(testPageKey)
+	})
+}
+
+func TestPageTokens(t *testing.T) {
+	tokens := NewPageTokens(testPageKey)
+
+	ordering, err := testOrderFields.Parse("createTime desc, name, rating")
+	require.NoError(t, err)
+
+	createTime := time.Date(2019, time.January, 1, 10, 0, 0, 5, time.UTC)
+	values, err := ordering.Values(map[string]interface{}{
+		"createTime": createTime,
+		"name":       "foo",
+		"rating":     int32(3),
+	})
+	require.NoError(t, err)
+
+	token, err := tokens.Encode(`name:"foo"  rating>2`, ordering, values)
+	require.NoError(t, err)
+
+	decoded, err := tokens.Decode(token, `name:"foo" rating>2`, ordering)
+	require.NoError(t, err)
+	require.Equal(t, []interface{}{createTime, "foo", int64(3)}, decoded)
+
+	_, err = tokens.Decode(token, `rating>2 AND name:"\x66oo"`, ordering)
+	require.NoError(t, err)
+}
+
+func TestPageTokensNullValues(t *testing.T) {
+	tokens := NewPageTokens(testPageKey)
+
+	ordering, err := testOrderFields.Parse("name, rating")
+	require.NoError(t, err)
+
+	token, err := tokens.Encode("", ordering, []interface{}{"foo", nil})
+	require.NoError(t, err)
+
+	decoded, err := tokens.Decode(token, "", ordering)
+	require.NoError(t, err)
+	require.Equal(t, []interface{}{"foo", nil}, decoded)
+}
+
+func TestPageTokensRejected(t *testing.T) {
+	tokens := NewPageTokens(testPageKey)
+
+	ordering, err := testOrderFields.Parse("name")
+	require.NoError(t, err)
+	other, err := testOrderFields.Parse("name desc")
+	require.NoError(t, err)
+
+	token, err := tokens.Encode(`rating>2`, ordering, []interface{}{"foo"})
+	require.NoError(t, err)
+
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	require.NoError(t, err)
+	data[3] ^= 1
+	tampered := base64.RawURLEncoding.EncodeToString(data)
+
+	tests := []struct {
+		name     string
+		tokens   *PageTokens
+		token    string
+		filter   string
+		ordering Ordering
+	}{
+		{"other filter", tokens, token, `rating>3`, ordering},
+		{"other order", tokens, token, `rating>2`, other},
+		{"other key", NewPageTokens([]byte("another key of at least 32 bytes")), token, `rating>2`, ordering},
+		{"tampered", tokens, tampered, `rating>2`, ordering},
+		{"garbage", tokens, "foo", `rating>2`, ordering},
+		{"empty", tokens, "", `rating>2`, ordering},
+	}
+	for _, test := range tests {
+		_, err := test.tokens.Decode(test.token, test.filter, test.ordering)
+		require.Error(t, err, test.name)
+		require.Equal(t, codes.InvalidArgument, status.Code(err), test.name)
+	}
+}
@@ -1,46 +1,147 @@
 package expr
 
 import (
+	"fmt"
+	"math/big"
+	"strconv"
 	"strings"
 	"time"
 
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"libs.altipla.consulting/errors"
 
 	"github.com/altipla-consulting/expr/parse"
 )
 
 type ParamOption func(f *Filter)
 
+func newFilter(f *Filter, opts []ParamOption) *Filter {
+	for _, opt := range opts {
+		opt(f)
+	}
+	if (f.min != nil || f.max != nil) && !f.numeric {
+		panic(fmt.Sprintf("min and max options are only allowed in numeric filters: %v", f.name))
+	}
+	return f
+}
+
 func Required() ParamOption {
 	return func(f *Filter) {
 		f.required = true
 	}
 }
 
-func IDParam(name string, opts ...ParamOption) *Filter {
-	return &Filter{
-		name:      name,
-		operators: []parse.Operator{parse.OpEqual, parse.OpNotEqual},
-		eval: func(value parse.Node) (interface{}, error) {
-			switch v := value.(type) {
-			case *parse.NumberNode:
-				if v.Val < 0 {
-					return nil, status.Errorf(codes.InvalidArgument, "id field cannot be negative: %v: %d", name, v.Val)
-				}
-				return v.Val, nil
+// Operators reemplaza la lista de operadores que se aceptan en el filtro.
+func Operators(operators ...parse.Operator) ParamOption {
+	return func(f *Filter) {
+		f.operators = operators
+	}
+}
 
-			default:
-				return nil, status.Errorf(codes.InvalidArgument, "id fields require numeric filters: %v: %v", name, value)
+// Column indica la columna de la base de datos del filtro en lugar de
+// deducirla del nombre. Se puede indicar la tabla delante separada por un punto
+// para las columnas de tablas unidas con JOIN.
+func Column(name string) ParamOption {
+	c := parseColumn(mustIdentifier(name))
+	return func(f *Filter) {
+		f.column = c
+	}
+}
+
+// JSONColumn filtra por un valor dentro de una columna JSON siguiendo el camino
+// de claves indicado. En PostgreSQL el valor se convierte al tipo del filtro.
+func JSONColumn(name string, path ...string) ParamOption {
+	c := parseColumn(mustIdentifier(name))
+	for _, key := range path {
+		c.jsonPath = append(c.jsonPath, mustIdentifier(key))
+	}
+	return func(f *Filter) {
+		f.column = c
+	}
+}
+
+// ColumnExpr usa una expresión SQL arbitraria en lugar de una columna. La
+// expresión se escribe tal cual en la consulta, sin escapar.
+func ColumnExpr(expr string) ParamOption {
+	return func(f *Filter) {
+		f.column = column{expr: expr}
+	}
+}
+
+// Alias permite usar otro nombre en las consultas para el mismo filtro, por
+// ejemplo para no romper clientes antiguos cuando se renombra un campo.
+func Alias(name string) ParamOption {
+	return func(f *Filter) {
+		f.aliases = append(f.aliases, name)
+	}
+}
+
+func Deprecated(msg string) ParamOption {
+	return func(f *Filter) {
+		f.deprecated = msg
+	}
+}
+
+func Description(text string) ParamOption {
+	return func(f *Filter) {
+		f.description = text
+	}
+}
+
+// MaxValues cambia para este filtro el máximo de valores por defecto de
+// MaxInValues en los operadores de conjuntos.
+func MaxValues(n int) ParamOption {
+	return func(f *Filter) {
+		f.maxValues = n
+	}
+}
+
+// Min limita el valor mínimo que se acepta en la consulta. Solo se puede usar
+// en los filtros numéricos.
+func Min(min int64) ParamOption {
+	return func(f *Filter) {
+		f.min = &min
+	}
+}
+
+// Max limita el valor máximo que se acepta en la consulta. Solo se puede usar
+// en los filtros numéricos.
+func Max(max int64) ParamOption {
+	return func(f *Filter) {
+		f.max = &max
+	}
+}
+
+func IDParam(name string, opts ...ParamOption) *Filter {
+	f := &Filter{
+		name:       name,
+		columnType: columnInteger,
+		operators:  []parse.Operator{parse.OpEqual, parse.OpNotEqual, parse.OpIn},
+		numeric:    true,
+	}
+	f.eval = func(value parse.Node) (interface{}, error) {
+		switch v := value.(type) {
+		case *parse.NumberNode:
+			if v.Val < 0 {
+				return nil, status.Errorf(codes.InvalidArgument, "id field cannot be negative: %v: %d", name, v.Val)
 			}
-		},
+			if err := f.checkBounds("id", v.String(), func(bound int64) int { return compareInt64(v.Val, bound) }); err != nil {
+				return nil, errors.Trace(err)
+			}
+			return v.Val, nil
+
+		default:
+			return nil, status.Errorf(codes.InvalidArgument, "id fields require numeric filters: %v: %v", name, value)
+		}
 	}
+	return newFilter(f, opts)
 }
 
 func EnumParam(name string, values map[string]int32, opts ...ParamOption) *Filter {
-	return &Filter{
+	return newFilter(&Filter{
 		name:      name,
-		operators: []parse.Operator{parse.OpEqual, parse.OpNotEqual},
+		operators: []parse.Operator{parse.OpEqual, parse.OpNotEqual, parse.OpIn},
 		eval: func(value parse.Node) (interface{}, error) {
 			switch v := value.(type) {
 			case *parse.ConstantNode:
@@ -56,13 +157,14 @@ func EnumParam(name string, values map[string]int32, opts ...ParamOption) *Filte
 				return nil, status.Errorf(codes.InvalidArgument, "enum fields require constants filters: %v: %v", name, value)
 			}
 		},
-	}
+	}, opts)
 }
 
 func BoolParam(name string, opts ...ParamOption) *Filter {
-	return &Filter{
-		name:      name,
-		operators: []parse.Operator{parse.OpEqual, parse.OpNotEqual},
+	return newFilter(&Filter{
+		name:       name,
+		columnType: columnBool,
+		operators:  []parse.Operator{parse.OpEqual, parse.OpNotEqual},
 		eval: func(value parse.Node) (interface{}, error) {
 			switch v := value.(type) {
 			case *parse.ConstantNode:
@@ -78,13 +180,14 @@ func BoolParam(name string, opts ...ParamOption) *Filter {
 				return nil, status.Errorf(codes.InvalidArgument, "boolean fields require boolean filters: %v: %v", name, value)
 			}
 		},
-	}
+	}, opts)
 }
 
 func TimestampParam(name string, opts ...ParamOption) *Filter {
-	return &Filter{
-		name:      name,
-		operators: []parse.Operator{parse.OpExists, parse.OpGreaterThan, parse.OpGreaterOrEqualThan, parse.OpLessThan, parse.OpLessOrEqualThan},
+	return newFilter(&Filter{
+		name:       name,
+		columnType: columnTimestamp,
+		operators:  []parse.Operator{parse.OpExists, parse.OpGreaterThan, parse.OpGreaterOrEqualThan, parse.OpLessThan, parse.OpLessOrEqualThan},
 		eval: func(value parse.Node) (interface{}, error) {
 			switch v := value.(type) {
 			case *parse.StringNode:
@@ -106,13 +209,13 @@ func TimestampParam(name string, opts ...ParamOption) *Filter {
 				return nil, status.Errorf(codes.InvalidArgument, "timestamp fields require string filters: %v: %v", name, value)
 			}
 		},
-	}
+	}, opts)
 }
 
 func StringParam(name string, opts ...ParamOption) *Filter {
-	return &Filter{
+	return newFilter(&Filter{
 		name:      name,
-		operators: []parse.Operator{parse.OpEqual, parse.OpNotEqual, parse.OpContains},
+		operators: []parse.Operator{parse.OpEqual, parse.OpNotEqual, parse.OpContains, parse.OpIn},
 		eval: func(value parse.Node) (interface{}, error) {
 			switch v := value.(type) {
 			case *parse.ConstantNode:
@@ -125,5 +228,158 @@ func StringParam(name string, opts ...ParamOption) *Filter {
 				return nil, status.Errorf(codes.InvalidArgument, "string fields require string filters: %v: %v", name, value)
 			}
 		},
+	}, opts)
+}
+
+func IntParam(name string, opts ...ParamOption) *Filter {
+	f := &Filter{
+		name:       name,
+		columnType: columnInteger,
+		operators:  []parse.Operator{parse.OpEqual, parse.OpNotEqual, parse.OpGreaterThan, parse.OpGreaterOrEqualThan, parse.OpLessThan, parse.OpLessOrEqualThan, parse.OpIn},
+		numeric:    true,
+	}
+	f.eval = func(value parse.Node) (interface{}, error) {
+		switch v := value.(type) {
+		case *parse.NumberNode:
+			if err := f.checkBounds("int", v.String(), func(bound int64) int { return compareInt64(v.Val, bound) }); err != nil {
+				return nil, errors.Trace(err)
+			}
+			return v.Val, nil
+
+		default:
+			return nil, status.Errorf(codes.InvalidArgument, "int fields require numeric filters: %v: %v", name, value)
+		}
+	}
+	return newFilter(f, opts)
+}
+
+func FloatParam(name string, opts ...ParamOption) *Filter {
+	f := &Filter{
+		name:       name,
+		columnType: columnFloat,
+		operators:  []parse.Operator{parse.OpEqual, parse.OpNotEqual, parse.OpGreaterThan, parse.OpGreaterOrEqualThan, parse.OpLessThan, parse.OpLessOrEqualThan},
+		numeric:    true,
+	}
+	f.eval = func(value parse.Node) (interface{}, error) {
+		var val float64
+		switch v := value.(type) {
+		case *parse.NumberNode:
+			val = float64(v.Val)
+
+		case *parse.FloatNode:
+			val = v.Val
+
+		default:
+			return nil, status.Errorf(codes.InvalidArgument, "float fields require numeric filters: %v: %v", name, value)
+		}
+
+		if err := f.checkBounds("float", value.String(), func(bound int64) int { return compareFloat64(val, float64(bound)) }); err != nil {
+			return nil, errors.Trace(err)
+		}
+		return val, nil
+	}
+	return newFilter(f, opts)
+}
+
+// Decimal es un número con la representación textual exacta que se ha escrito
+// en la consulta, sin pasar por float64. Se envía tal cual a la base de datos
+// como string para compararlo con columnas DECIMAL sin perder precisión.
+type Decimal string
+
+func (d Decimal) rat() *big.Rat {
+	r, ok := new(big.Rat).SetString(string(d))
+	if !ok {
+		return nil
+	}
+	return r
+}
+
+func DecimalParam(name string, opts ...ParamOption) *Filter {
+	f := &Filter{
+		name:       name,
+		columnType: columnDecimal,
+		operators:  []parse.Operator{parse.OpEqual, parse.OpNotEqual, parse.OpGreaterThan, parse.OpGreaterOrEqualThan, parse.OpLessThan, parse.OpLessOrEqualThan},
+		numeric:    true,
+	}
+	f.eval = func(value parse.Node) (interface{}, error) {
+		var d Decimal
+		switch v := value.(type) {
+		case *parse.NumberNode:
+			d = Decimal(strconv.FormatInt(v.Val, 10))
+
+		case *parse.FloatNode:
+			var ok bool
+			d, ok = decimalFromText(v.Text)
+			if !ok {
+				return nil, status.Errorf(codes.InvalidArgument, "decimal field out of range: %v: %s", name, v.Text)
+			}
+
+		default:
+			return nil, status.Errorf(codes.InvalidArgument, "decimal fields require numeric filters: %v: %v", name, value)
+		}
+
+		if f.min != nil || f.max != nil {
+			r := d.rat()
+			if r == nil {
+				return nil, status.Errorf(codes.InvalidArgument, "decimal field out of range: %v: %s", name, d)
+			}
+			if err := f.checkBounds("decimal", string(d), func(bound int64) int { return r.Cmp(new(big.Rat).SetInt64(bound)) }); err != nil {
+				return nil, errors.Trace(err)
+			}
+		}
+		return d, nil
+	}
+	return newFilter(f, opts)
+}
+
+// checkBounds comprueba el valor contra los límites de Min y Max. La función
+// cmp compara el valor con cada límite devolviendo -1, 0 o 1.
+func (f *Filter) checkBounds(kind, text string, cmp func(bound int64) int) error {
+	if f.min != nil && cmp(*f.min) < 0 {
+		return status.Errorf(codes.InvalidArgument, "%s field below the minimum value: %v: %s < %d", kind, f.name, text, *f.min)
+	}
+	if f.max != nil && cmp(*f.max) > 0 {
+		return status.Errorf(codes.InvalidArgument, "%s field above the maximum value: %v: %s > %d", kind, f.name, text, *f.max)
+	}
+	return nil
+}
+
+// maxDecimalScale limita las cifras que puede tener un decimal al expandir el
+// exponente para que una consulta corta no genere números enormes.
+const maxDecimalScale = 100
+
+// decimalFromText normaliza el texto de un número quitando el signo positivo y
+// expandiendo el exponente si lo tiene. Las cifras decimales que se hayan
+// escrito, incluidos los ceros a la derecha, se conservan. Devuelve false si el
+// exponente es demasiado grande para expandirlo.
+func decimalFromText(text string) (Decimal, bool) {
+	text = strings.TrimPrefix(text, "+")
+
+	exp := strings.IndexAny(text, "eE")
+	if exp == -1 {
+		return Decimal(text), true
+	}
+
+	mantissa := text[:exp]
+	e, err := strconv.Atoi(text[exp+1:])
+	if err != nil || e > maxDecimalScale || e < -maxDecimalScale {
+		return "", false
+	}
+	var scale int
+	if dot := strings.Index(mantissa, "."); dot != -1 {
+		scale = len(mantissa) - dot - 1
+	}
+	scale -= e
+	if scale < 0 {
+		scale = 0
+	}
+	if scale > maxDecimalScale {
+		return "", false
+	}
+
+	r, ok := new(big.Rat).SetString(text)
+	if !ok {
+		return "", false
 	}
+	return Decimal(r.FloatString(scale)), true
 }
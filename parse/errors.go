@@ -0,0 +1,57 @@
+package parse
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+type SyntaxError struct {
+	// Consulta completa en la que se ha producido el error.
+	Query string
+
+	// Posición del error en bytes desde el principio de la consulta.
+	Offset int
+
+	// Línea y columna del error, empezando ambas en 1. La columna se mide en
+	// caracteres y no en bytes.
+	Line, Column int
+
+	// Texto del token que ha provocado el error. Vacío si es el final de la
+	// consulta.
+	Token string
+
+	// Tipos de token que se esperaban en esa posición.
+	Expected []string
+
+	Msg string
+}
+
+func newSyntaxError(query string, offset int, token string, expected []string, msg string) *SyntaxError {
+	before := query[:offset]
+	lineStart := strings.LastIndex(before, "\n") + 1
+	return &SyntaxError{
+		Query:    query,
+		Offset:   offset,
+		Line:     strings.Count(before, "\n") + 1,
+		Column:   utf8.RuneCountInString(before[lineStart:]) + 1,
+		Token:    token,
+		Expected: expected,
+		Msg:      msg,
+	}
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("%d:%d: %s", e.Line, e.Column, e.Msg)
+}
+
+// Caret devuelve la línea de la consulta que contiene el error con un ^ debajo
+// de la posición exacta donde se ha producido.
+//
+//	foo=3 bar=)
+//	          ^
+func (e *SyntaxError) Caret() string {
+	lines := strings.Split(e.Query, "\n")
+	line := lines[e.Line-1]
+	return line + "\n" + strings.Repeat(" ", e.Column-1) + "^"
+}
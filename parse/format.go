@@ -0,0 +1,143 @@
+package parse
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type FormatOption func(opts *formatOptions)
+
+type formatOptions struct {
+	notKeyword  bool
+	explicitAnd bool
+	sortTerms   bool
+	normalize   bool
+}
+
+// WithNotKeyword escribe las comparaciones negadas con NOT en lugar de con el
+// signo menos delante.
+func WithNotKeyword() FormatOption {
+	return func(opts *formatOptions) {
+		opts.notKeyword = true
+	}
+}
+
+// WithExplicitAnd separa los términos con AND en lugar de con espacios.
+func WithExplicitAnd() FormatOption {
+	return func(opts *formatOptions) {
+		opts.explicitAnd = true
+	}
+}
+
+// WithSortedTerms ordena los términos de los AND, los OR y las listas de
+// valores para que dos consultas equivalentes escritas en distinto orden
+// generen el mismo texto.
+func WithSortedTerms() FormatOption {
+	return func(opts *formatOptions) {
+		opts.sortTerms = true
+	}
+}
+
+// WithNormalizedValues reescribe los valores con su forma canónica: strings
+// con las comillas y escapes de strconv.Quote y números sin signo positivo
+// ni exponente en mayúsculas.
+func WithNormalizedValues() FormatOption {
+	return func(opts *formatOptions) {
+		opts.normalize = true
+	}
+}
+
+// Format escribe la consulta con la sintaxis del parser de forma que se pueda
+// volver a leer con Parse y obtener el mismo árbol. Sin opciones los valores se
+// escriben tal cual se leyeron.
+func Format(node Node, opts ...FormatOption) string {
+	options := new(formatOptions)
+	for _, opt := range opts {
+		opt(options)
+	}
+	return options.format(node)
+}
+
+func (opts *formatOptions) format(node Node) string {
+	switch node := node.(type) {
+	case *AndNode:
+		sep := " "
+		if opts.explicitAnd {
+			sep = " AND "
+		}
+		return strings.Join(opts.formatAll(node.Nodes), sep)
+
+	case *OrNode:
+		return strings.Join(opts.formatAll(node.Nodes), " OR ")
+
+	case *GroupNode:
+		s := "(" + opts.format(node.Expr) + ")"
+		if node.Negative {
+			return "NOT " + s
+		}
+		return s
+
+	case *ExprNode:
+		var s string
+		if node.Negative {
+			if opts.notKeyword {
+				s = "NOT "
+			} else {
+				s = "-"
+			}
+		}
+		s += node.Field.Name
+		if node.Op.Val == OpIn {
+			s += " IN "
+		} else {
+			s += string(node.Op.Val)
+		}
+		if node.Val != nil {
+			s += opts.format(node.Val)
+		}
+		return s
+
+	case *ListNode:
+		return "(" + strings.Join(opts.formatAll(node.Vals), ", ") + ")"
+
+	case *StringNode:
+		if opts.normalize {
+			if u, err := strconv.Unquote(node.Quoted); err == nil {
+				return strconv.Quote(u)
+			}
+		}
+		return node.Quoted
+
+	case *FloatNode:
+		if opts.normalize {
+			return strings.ToLower(strings.TrimPrefix(node.Text, "+"))
+		}
+		return node.Text
+
+	case *NumberNode:
+		return strconv.FormatInt(node.Val, 10)
+
+	case *ConstantNode:
+		return node.Name
+
+	case *FieldNode:
+		return node.Name
+
+	case *OperatorNode:
+		return string(node.Val)
+	}
+
+	return node.String()
+}
+
+func (opts *formatOptions) formatAll(nodes []Node) []string {
+	s := make([]string, len(nodes))
+	for i, node := range nodes {
+		s[i] = opts.format(node)
+	}
+	if opts.sortTerms {
+		sort.Strings(s)
+	}
+	return s
+}
@@ -0,0 +1,195 @@
+package parse
+
+import (
+	"math/rand"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var formatCorpus = []string{
+	``,
+	`foo=3 bar:*`,
+	`foo=3 OR bar=4 baz=5`,
+	`(foo=3 OR bar=4) baz=5`,
+	`foo=3 OR (bar=4 baz=5)`,
+	`((foo=3))`,
+	`NOT foo=3`,
+	`-foo=3`,
+	`NOT (foo=3 OR bar=4)`,
+	`-(foo=3) bar=4`,
+	`foo=3 AND bar=4 OR baz=5 AND NOT qux:*`,
+	`id IN (1,2, 3)`,
+	`NOT status IN (ACTIVE, "PENDING") OR id=3`,
+	`price>=10.50 rating<4E-1 delta=-3 gain=+1.5 count=+3`,
+	`name:"foo \"bar\" \\ baz" other="\x66ñ"`,
+	`a.b-c!=FOO d<="2019-01-01T00:00:00Z"`,
+	`ANDROID=1 AND ORDER IN (1) OR NOTE:* INDEX=2 NOT INside=3 and=4 or:* not IN (1)`,
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		{``, ``},
+		{`a=1   b:"foo bar"`, `a=1 b:"foo bar"`},
+		{`NOT a=1`, `-a=1`},
+		{`a=1 AND b=2`, `a=1 b=2`},
+		{`a IN (1,2)`, `a IN (1, 2)`},
+		{`-(a=1 OR b:*) c>=1.50`, `NOT (a=1 OR b:*) c>=1.50`},
+	}
+	for _, test := range tests {
+		root, err := Parse(test.query)
+		require.NoError(t, err, test.query)
+		require.Equal(t, test.expected, Format(root), test.query)
+		require.Equal(t, test.expected, root.String(), test.query)
+	}
+}
+
+func TestFormatOptions(t *testing.T) {
+	tests := []struct {
+		query    string
+		opts     []FormatOption
+		expected string
+	}{
+		{`-a=1 -(b=2)`, []FormatOption{WithNotKeyword()}, `NOT a=1 NOT (b=2)`},
+		{`a=1 b=2 OR c=3 (d=4 e=5)`, []FormatOption{WithExplicitAnd()}, `a=1 AND b=2 OR c=3 AND (d=4 AND e=5)`},
+		{`c=3 a=1 OR b=2 x IN (3, 1, 2)`, []FormatOption{WithSortedTerms()}, `a=1 OR b=2 c=3 x IN (1, 2, 3)`},
+		{`a="\x66oo" b=+1.5E3 c=+3`, []FormatOption{WithNormalizedValues()}, `a="foo" b=1.5e3 c=3`},
+		{`b="ñ" a=1 AND -c:*`, []FormatOption{WithSortedTerms(), WithNormalizedValues(), WithExplicitAnd(), WithNotKeyword()}, `NOT c:* AND a=1 AND b="ñ"`},
+	}
+	for _, test := range tests {
+		root, err := Parse(test.query)
+		require.NoError(t, err, test.query)
+		require.Equal(t, test.expected, Format(root, test.opts...), test.query)
+	}
+}
+
+func TestFormatRoundTrip(t *testing.T) {
+	exact := [][]FormatOption{
+		nil,
+		{WithNotKeyword()},
+		{WithExplicitAnd()},
+		{WithNotKeyword(), WithExplicitAnd()},
+	}
+	canonical := [][]FormatOption{
+		{WithSortedTerms()},
+		{WithNormalizedValues()},
+		{WithSortedTerms(), WithNormalizedValues(), WithExplicitAnd()},
+	}
+
+	for _, query := range formatCorpus {
+		root, err := Parse(query)
+		require.NoError(t, err, query)
+
+		// Sin reordenar ni normalizar los valores el árbol tiene que ser idéntico.
+		for _, opts := range exact {
+			again, err := Parse(Format(root, opts...))
+			require.NoError(t, err, query)
+			require.Equal(t, root, again, query)
+		}
+
+		// El resto de opciones tienen que dar siempre el mismo texto.
+		for _, opts := range canonical {
+			formatted := Format(root, opts...)
+			again, err := Parse(formatted)
+			require.NoError(t, err, query)
+			require.Equal(t, formatted, Format(again, opts...), query)
+		}
+	}
+}
+
+func TestFormatRandomTrees(t *testing.T) {
+	g := &treeGenerator{rnd: rand.New(rand.NewSource(1))}
+	for i := 0; i < 2000; i++ {
+		root := g.and(3)
+		query := Format(root)
+
+		parsed, err := Parse(query)
+		require.NoError(t, err, query)
+		require.Equal(t, root, parsed, query)
+	}
+}
+
+// treeGenerator construye árboles aleatorios con la misma forma que los que
+// devuelve el parser.
+type treeGenerator struct {
+	rnd *rand.Rand
+}
+
+func (g *treeGenerator) and(depth int) *AndNode {
+	n := &AndNode{NodeType: NodeAnd}
+	for i := g.rnd.Intn(3) + 1; i > 0; i-- {
+		n.Nodes = append(n.Nodes, g.or(depth))
+	}
+	return n
+}
+
+func (g *treeGenerator) or(depth int) Node {
+	if g.rnd.Intn(3) > 0 {
+		return g.term(depth)
+	}
+	n := &OrNode{NodeType: NodeOr}
+	for i := g.rnd.Intn(2) + 2; i > 0; i-- {
+		n.Nodes = append(n.Nodes, g.term(depth))
+	}
+	return n
+}
+
+func (g *treeGenerator) term(depth int) Node {
+	if depth > 0 && g.rnd.Intn(4) == 0 {
+		return &GroupNode{
+			NodeType: NodeGroup,
+			Expr:     g.and(depth - 1),
+			Negative: g.rnd.Intn(2) == 0,
+		}
+	}
+
+	fields := []string{"a", "b.c", "x-1", "Field2"}
+	expr := &ExprNode{
+		NodeType: NodeExpr,
+		Field:    &FieldNode{NodeType: NodeField, Name: fields[g.rnd.Intn(len(fields))]},
+		Op:       &OperatorNode{NodeType: NodeOperator, Val: allOperators[g.rnd.Intn(len(allOperators))]},
+		Negative: g.rnd.Intn(3) == 0,
+	}
+	switch expr.Op.Val {
+	case OpExists:
+	case OpIn:
+		list := &ListNode{NodeType: NodeList}
+		for i := g.rnd.Intn(3) + 1; i > 0; i-- {
+			list.Vals = append(list.Vals, g.value())
+		}
+		expr.Val = list
+	default:
+		expr.Val = g.value()
+	}
+	return expr
+}
+
+func (g *treeGenerator) value() Node {
+	switch g.rnd.Intn(4) {
+	case 0:
+		return &NumberNode{NodeType: NodeNumber, Val: g.rnd.Int63() - g.rnd.Int63()}
+
+	case 1:
+		text := strconv.FormatFloat(g.rnd.NormFloat64()*1e6, 'g', -1, 64)
+		if _, err := strconv.ParseInt(text, 10, 64); err == nil {
+			text += ".0"
+		}
+		val, _ := strconv.ParseFloat(text, 64)
+		return &FloatNode{NodeType: NodeFloat, Val: val, Text: text}
+
+	case 2:
+		runes := []rune{'a', 'Z', '0', ' ', '"', '\\', '\n', 'ñ', '😀', ')', ',', '=', '\x00'}
+		s := make([]rune, g.rnd.Intn(8))
+		for i := range s {
+			s[i] = runes[g.rnd.Intn(len(runes))]
+		}
+		return &StringNode{NodeType: NodeString, Quoted: strconv.Quote(string(s))}
+	}
+
+	constants := []string{"FOO", "true", "false", "ACTIVE_2"}
+	return &ConstantNode{NodeType: NodeConstant, Name: constants[g.rnd.Intn(len(constants))]}
+}
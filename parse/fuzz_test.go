@@ -0,0 +1,62 @@
+//go:build go1.18
+// +build go1.18
+
+package parse
+
+import (
+	"runtime"
+	"testing"
+	"unicode/utf8"
+)
+
+func FuzzParse(f *testing.F) {
+	for _, query := range formatCorpus {
+		f.Add(query)
+	}
+	for _, query := range invalidQueries {
+		f.Add(query)
+	}
+	for _, query := range []string{`foo="bar`, `foo="\"`, `foo:*`, `foo:* bar=1`, `foo:*bar`, `ñ=3`, "foo=\"\xff\"", `foo=3 )`} {
+		f.Add(query)
+	}
+
+	f.Fuzz(func(t *testing.T, query string) {
+		before := runtime.NumGoroutine()
+		root, err := Parse(query)
+		if n := runtime.NumGoroutine(); n > before {
+			t.Fatalf("goroutines leaked: %d > %d", n, before)
+		}
+
+		if err != nil {
+			serr, ok := err.(*SyntaxError)
+			if !ok {
+				t.Fatalf("unexpected error type %T: %v", err, err)
+			}
+			if serr.Offset < 0 || serr.Offset > len(query) {
+				t.Fatalf("error offset out of range: %d", serr.Offset)
+			}
+			if serr.Line < 1 || serr.Column < 1 || serr.Column > utf8.RuneCountInString(query)+1 {
+				t.Fatalf("error position out of range: %d:%d", serr.Line, serr.Column)
+			}
+			return
+		}
+
+		formatted := Format(root)
+		again, err := Parse(formatted)
+		if err != nil {
+			t.Fatalf("cannot parse formatted query %q: %v", formatted, err)
+		}
+		if Format(again) != formatted {
+			t.Fatalf("unstable format: %q != %q", Format(again), formatted)
+		}
+
+		canonical := Format(root, WithSortedTerms(), WithNormalizedValues(), WithExplicitAnd(), WithNotKeyword())
+		again, err = Parse(canonical)
+		if err != nil {
+			t.Fatalf("cannot parse canonical query %q: %v", canonical, err)
+		}
+		if c := Format(again, WithSortedTerms(), WithNormalizedValues(), WithExplicitAnd(), WithNotKeyword()); c != canonical {
+			t.Fatalf("unstable canonical format: %q != %q", c, canonical)
+		}
+	})
+}
@@ -2,6 +2,7 @@ package parse
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 	"unicode"
 	"unicode/utf8"
@@ -19,6 +20,10 @@ const (
 	itemConstant
 	itemAnd
 	itemNot
+	itemOr
+	itemLeftParen
+	itemRightParen
+	itemFloat
 )
 
 const eof = -1
@@ -26,6 +31,7 @@ const eof = -1
 type item struct {
 	typ itemType
 	val string
+	pos int
 }
 
 func (i item) String() string {
@@ -42,34 +48,42 @@ func (i item) String() string {
 		return fmt.Sprintf("string:%q", i.val)
 	case itemNumber:
 		return fmt.Sprintf("number:%q", i.val)
+	case itemFloat:
+		return fmt.Sprintf("float:%q", i.val)
 	case itemConstant:
 		return fmt.Sprintf("const:%q", i.val)
 	case itemAnd:
 		return " AND "
 	case itemNot:
 		return "NOT "
+	case itemOr:
+		return " OR "
+	case itemLeftParen:
+		return "("
+	case itemRightParen:
+		return ")"
 	}
 	panic(fmt.Sprintf("should not reach here: %v", i.typ))
 }
 
 type stateFn func(l *lexer) stateFn
 
+// lexer analiza la consulta bajo demanda: cada vez que el parser pide un token
+// se ejecutan los estados necesarios hasta que alguno lo emite. Los tokens
+// pendientes se guardan en un buffer fijo para no reservar memoria en cada uno.
 type lexer struct {
 	input      string
 	start, pos int
 	width      int
-	items      chan item
-}
-
-func (l *lexer) run() {
-	for state := lexStart; state != nil; {
-		state = state(l)
-	}
-	close(l.items)
+	state      stateFn
+	inList     bool
+	items      []item
+	buf        [2]item
+	err        *SyntaxError
 }
 
 func (l *lexer) emit(t itemType) {
-	l.items <- item{t, l.input[l.start:l.pos]}
+	l.items = append(l.items, item{t, l.input[l.start:l.pos], l.start})
 	l.start = l.pos
 }
 
@@ -106,27 +120,69 @@ func (l *lexer) accept(valid string) bool {
 	return false
 }
 
+func (l *lexer) acceptDigits() bool {
+	pos := l.pos
+	l.acceptRun("0123456789")
+	return l.pos > pos
+}
+
 func (l *lexer) acceptRun(valid string) {
 	for strings.IndexRune(valid, l.next()) >= 0 { // revive:disable-line:empty-block
 	}
 	l.backup()
 }
 
-func (l *lexer) errorf(format string, args ...interface{}) stateFn {
-	l.items <- item{
-		itemError,
-		fmt.Sprintf(format, args...),
+// acceptKeyword consume la palabra reservada si aparece en la posición actual
+// seguida de un espacio o un paréntesis.
+func (l *lexer) acceptKeyword(keyword string) bool {
+	if !strings.HasPrefix(l.input[l.pos:], keyword) {
+		return false
+	}
+	rest := l.input[l.pos+len(keyword):]
+	if rest != "" && rest[0] != ' ' && rest[0] != '(' {
+		return false
+	}
+	l.pos += len(keyword)
+	return true
+}
+
+// errorf guarda el error con la posición del token actual y termina el
+// análisis. Si el token está vacío se señala el siguiente carácter.
+func (l *lexer) errorf(expected []string, format string, args ...interface{}) stateFn {
+	token := l.input[l.start:l.pos]
+	if token == "" && l.start < len(l.input) {
+		_, w := utf8.DecodeRuneInString(l.input[l.start:])
+		token = l.input[l.start : l.start+w]
 	}
+	l.err = newSyntaxError(l.input, l.start, token, expected, fmt.Sprintf(format, args...))
+
+	l.items = append(l.items, item{itemError, l.err.Msg, l.start})
 	return nil
 }
 
+// nextItem devuelve el siguiente token o un item vacío cuando la consulta ya se
+// ha terminado de analizar.
 func (l *lexer) nextItem() item {
-	return <-l.items
+	for len(l.items) == 0 {
+		if l.state == nil {
+			return item{}
+		}
+		l.items = l.buf[:0]
+		l.state = l.state(l)
+	}
+
+	tok := l.items[0]
+	l.items = l.items[1:]
+	return tok
 }
 
-func (l *lexer) drain() {
-	for range l.items { // revive:disable-line:empty-block
+// afterValue decide cómo continuar después de leer un valor dependiendo de si
+// forma parte de una lista o no.
+func (l *lexer) afterValue() stateFn {
+	if l.inList {
+		return lexListSeparator
 	}
+	return lexAnd
 }
 
 func (l *lexer) ignoreSpaces() {
@@ -135,12 +191,10 @@ func (l *lexer) ignoreSpaces() {
 }
 
 func lex(input string) *lexer {
-	l := &lexer{
+	return &lexer{
 		input: input,
-		items: make(chan item),
+		state: lexStart,
 	}
-	go l.run()
-	return l
 }
 
 func lexField(l *lexer) stateFn {
@@ -150,11 +204,31 @@ func lexField(l *lexer) stateFn {
 		l.next()
 		l.ignore()
 		l.emit(itemNot)
+		return lexTerm
+	}
+
+	if l.acceptKeyword("NOT") {
+		l.ignore()
+		l.emit(itemNot)
+		l.ignoreSpaces()
+	}
+
+	return lexTerm
+}
+
+func lexTerm(l *lexer) stateFn {
+	if r := l.peek(); r == '(' {
+		l.next()
+		l.emit(itemLeftParen)
+		return lexField
 	}
 
 	l.acceptRun("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ1234567890-.")
 	if l.start == l.pos {
-		return l.errorf("field name: %q", l.input[l.start:])
+		return l.errorf([]string{"field", "("}, "field name: %q", l.input[l.start:])
+	}
+	if IsKeyword(l.input[l.start:l.pos]) {
+		return l.errorf([]string{"field", "("}, "reserved keyword used as field name: %s", l.input[l.start:l.pos])
 	}
 
 	l.emit(itemField)
@@ -163,10 +237,16 @@ func lexField(l *lexer) stateFn {
 
 func lexOperator(l *lexer) stateFn {
 	l.ignoreSpaces()
+
+	if l.acceptKeyword("IN") {
+		l.emit(itemOperator)
+		return lexList
+	}
+
 	l.acceptRun(":<=!>*")
 
 	if l.start == l.pos {
-		return l.errorf("empty operator")
+		return l.errorf([]string{"operator"}, "empty operator")
 	}
 
 	if l.input[l.start:l.pos] == ":*" {
@@ -186,6 +266,8 @@ func lexValue(l *lexer) stateFn {
 		return lexString
 	case isDigit(r):
 		return lexNumber
+	case (r == '-' || r == '+') && l.pos+1 < len(l.input) && isDigit(rune(l.input[l.pos+1])):
+		return lexNumber
 	default:
 		return lexConstant
 	}
@@ -203,54 +285,118 @@ Loop:
 			}
 			fallthrough
 		case eof:
-			return l.errorf("unterminated quoted string: %q", l.input[l.start:])
+			return l.errorf([]string{`"`}, "unterminated quoted string: %q", l.input[l.start:])
 		case '"':
 			break Loop
 		}
 	}
 
+	// Los escapes tienen que ser válidos para poder leer después el valor.
+	if _, err := strconv.Unquote(l.input[l.start:l.pos]); err != nil {
+		return l.errorf([]string{"string"}, "invalid quoted string: %s", l.input[l.start:l.pos])
+	}
+
 	l.emit(itemString)
-	return lexAnd
+	return l.afterValue()
 }
 
 func lexNumber(l *lexer) stateFn {
+	typ := itemNumber
+
 	l.accept("+-")
-	l.acceptRun("0123456789")
+	if !l.acceptDigits() {
+		return l.errorf([]string{"number"}, "unknown number: %q", l.input[l.start:])
+	}
 
-	if l.start == l.pos {
-		return l.errorf("unknown number: %q", l.input[l.start:])
+	// Parte decimal y exponente opcionales, que convierten el número en uno
+	// de coma flotante.
+	if l.accept(".") {
+		typ = itemFloat
+		if !l.acceptDigits() {
+			return l.errorf([]string{"number"}, "unknown number: %q", l.input[l.start:])
+		}
+	}
+	if l.accept("eE") {
+		typ = itemFloat
+		l.accept("+-")
+		if !l.acceptDigits() {
+			return l.errorf([]string{"number"}, "unknown number: %q", l.input[l.start:])
+		}
 	}
 
-	l.emit(itemNumber)
-	return lexAnd
+	l.emit(typ)
+	return l.afterValue()
 }
 
 func lexConstant(l *lexer) stateFn {
 	l.acceptRun("ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz_0123456789")
 
 	if l.start == l.pos {
-		return l.errorf("unknown constant: %q", l.input[l.start:])
+		return l.errorf([]string{"value"}, "unknown constant: %q", l.input[l.start:])
 	}
 
 	l.emit(itemConstant)
-	return lexAnd
+	return l.afterValue()
+}
+
+func lexList(l *lexer) stateFn {
+	l.ignoreSpaces()
+
+	if l.peek() != '(' {
+		return l.errorf([]string{"("}, "list of values: %q", l.input[l.start:])
+	}
+	l.next()
+	l.emit(itemLeftParen)
+	l.inList = true
+
+	return lexValue
+}
+
+func lexListSeparator(l *lexer) stateFn {
+	l.ignoreSpaces()
+
+	switch l.next() {
+	case ',':
+		l.ignore()
+		return lexValue
+	case ')':
+		l.emit(itemRightParen)
+		l.inList = false
+		return lexAnd
+	}
+	l.backup()
+
+	return l.errorf([]string{",", ")"}, "unterminated list of values: %q", l.input[l.start:])
 }
 
 func lexAnd(l *lexer) stateFn {
 	switch r := l.next(); r {
 	case ' ':
+	case ')':
+		l.emit(itemRightParen)
+		return lexAnd
 	case eof:
 		l.emit(itemEOF)
 		return nil
 	default:
-		return l.errorf("unknown character: %c", r)
+		return l.errorf([]string{"space", ")", "EOF"}, "unknown character: %c", r)
 	}
 	l.ignore()
 
 	l.ignoreSpaces()
-	if l.peek() == eof {
+	switch l.peek() {
+	case eof:
 		l.emit(itemEOF)
 		return nil
+	case ')':
+		return lexAnd
+	}
+
+	switch {
+	case l.acceptKeyword("AND"):
+		l.emit(itemAnd)
+	case l.acceptKeyword("OR"):
+		l.emit(itemOr)
 	}
 
 	return lexField
@@ -265,6 +411,16 @@ func lexStart(l *lexer) stateFn {
 	return lexField
 }
 
+// IsKeyword indica si el nombre es una de las palabras reservadas de la
+// gramática, que no se pueden usar como nombre de campo.
+func IsKeyword(name string) bool {
+	switch name {
+	case "AND", "OR", "NOT", "IN":
+		return true
+	}
+	return false
+}
+
 func isDigit(r rune) bool {
 	return unicode.IsDigit(r)
 }
@@ -14,131 +14,252 @@ func TestLexer(t *testing.T) {
 		{
 			query: ``,
 			expected: []item{
-				{itemAnd, ""},
-				{itemEOF, ""},
+				{typ: itemAnd, val: ""},
+				{typ: itemEOF, val: ""},
 			},
 		},
 		{
 			query: `foo=3`,
 			expected: []item{
-				{itemAnd, ""},
-				{itemField, "foo"}, {itemOperator, "="}, {itemNumber, "3"},
-				{itemEOF, ""},
+				{typ: itemAnd, val: ""},
+				{typ: itemField, val: "foo"}, {typ: itemOperator, val: "="}, {typ: itemNumber, val: "3"},
+				{typ: itemEOF, val: ""},
 			},
 		},
 		{
 			query: `foo=MY_CONSTANT`,
 			expected: []item{
-				{itemAnd, ""},
-				{itemField, "foo"}, {itemOperator, "="}, {itemConstant, "MY_CONSTANT"},
-				{itemEOF, ""},
+				{typ: itemAnd, val: ""},
+				{typ: itemField, val: "foo"}, {typ: itemOperator, val: "="}, {typ: itemConstant, val: "MY_CONSTANT"},
+				{typ: itemEOF, val: ""},
 			},
 		},
 		{
 			query: `foo:3 bar:"hola"`,
 			expected: []item{
-				{itemAnd, ""},
-				{itemField, "foo"}, {itemOperator, ":"}, {itemNumber, "3"},
-				{itemField, "bar"}, {itemOperator, ":"}, {itemString, `"hola"`},
-				{itemEOF, ""},
+				{typ: itemAnd, val: ""},
+				{typ: itemField, val: "foo"}, {typ: itemOperator, val: ":"}, {typ: itemNumber, val: "3"},
+				{typ: itemField, val: "bar"}, {typ: itemOperator, val: ":"}, {typ: itemString, val: `"hola"`},
+				{typ: itemEOF, val: ""},
 			},
 		},
 		{
 			query: `foo.bar=3`,
 			expected: []item{
-				{itemAnd, ""},
-				{itemField, "foo.bar"}, {itemOperator, "="}, {itemNumber, "3"},
-				{itemEOF, ""},
+				{typ: itemAnd, val: ""},
+				{typ: itemField, val: "foo.bar"}, {typ: itemOperator, val: "="}, {typ: itemNumber, val: "3"},
+				{typ: itemEOF, val: ""},
 			},
 		},
 		{
 			query: `foo:*`,
 			expected: []item{
-				{itemAnd, ""},
-				{itemField, "foo"}, {itemOperator, ":*"},
-				{itemEOF, ""},
+				{typ: itemAnd, val: ""},
+				{typ: itemField, val: "foo"}, {typ: itemOperator, val: ":*"},
+				{typ: itemEOF, val: ""},
 			},
 		},
 		{
 			query: `-foo=3`,
 			expected: []item{
-				{itemAnd, ""},
-				{itemNot, ""},
-				{itemField, "foo"}, {itemOperator, "="}, {itemNumber, "3"},
-				{itemEOF, ""},
+				{typ: itemAnd, val: ""},
+				{typ: itemNot, val: ""},
+				{typ: itemField, val: "foo"}, {typ: itemOperator, val: "="}, {typ: itemNumber, val: "3"},
+				{typ: itemEOF, val: ""},
 			},
 		},
 		{
 			query: `-foo:*`,
 			expected: []item{
-				{itemAnd, ""},
-				{itemNot, ""},
-				{itemField, "foo"}, {itemOperator, ":*"},
-				{itemEOF, ""},
+				{typ: itemAnd, val: ""},
+				{typ: itemNot, val: ""},
+				{typ: itemField, val: "foo"}, {typ: itemOperator, val: ":*"},
+				{typ: itemEOF, val: ""},
 			},
 		},
 		{
 			query: `fooBar:*`,
 			expected: []item{
-				{itemAnd, ""},
-				{itemField, "fooBar"}, {itemOperator, ":*"},
-				{itemEOF, ""},
+				{typ: itemAnd, val: ""},
+				{typ: itemField, val: "fooBar"}, {typ: itemOperator, val: ":*"},
+				{typ: itemEOF, val: ""},
 			},
 		},
 		{
 			query: `foo=true`,
 			expected: []item{
-				{itemAnd, ""},
-				{itemField, "foo"}, {itemOperator, "="}, {itemConstant, "true"},
-				{itemEOF, ""},
+				{typ: itemAnd, val: ""},
+				{typ: itemField, val: "foo"}, {typ: itemOperator, val: "="}, {typ: itemConstant, val: "true"},
+				{typ: itemEOF, val: ""},
 			},
 		},
 		{
 			query: `foo>3`,
 			expected: []item{
-				{itemAnd, ""},
-				{itemField, "foo"}, {itemOperator, ">"}, {itemNumber, "3"},
-				{itemEOF, ""},
+				{typ: itemAnd, val: ""},
+				{typ: itemField, val: "foo"}, {typ: itemOperator, val: ">"}, {typ: itemNumber, val: "3"},
+				{typ: itemEOF, val: ""},
 			},
 		},
 		{
 			query: `foo>=3`,
 			expected: []item{
-				{itemAnd, ""},
-				{itemField, "foo"}, {itemOperator, ">="}, {itemNumber, "3"},
-				{itemEOF, ""},
+				{typ: itemAnd, val: ""},
+				{typ: itemField, val: "foo"}, {typ: itemOperator, val: ">="}, {typ: itemNumber, val: "3"},
+				{typ: itemEOF, val: ""},
 			},
 		},
 		{
 			query: `foo<3`,
 			expected: []item{
-				{itemAnd, ""},
-				{itemField, "foo"}, {itemOperator, "<"}, {itemNumber, "3"},
-				{itemEOF, ""},
+				{typ: itemAnd, val: ""},
+				{typ: itemField, val: "foo"}, {typ: itemOperator, val: "<"}, {typ: itemNumber, val: "3"},
+				{typ: itemEOF, val: ""},
 			},
 		},
 		{
 			query: `foo<=3`,
 			expected: []item{
-				{itemAnd, ""},
-				{itemField, "foo"}, {itemOperator, "<="}, {itemNumber, "3"},
-				{itemEOF, ""},
+				{typ: itemAnd, val: ""},
+				{typ: itemField, val: "foo"}, {typ: itemOperator, val: "<="}, {typ: itemNumber, val: "3"},
+				{typ: itemEOF, val: ""},
 			},
 		},
 		{
 			query: `ts>"2019-03-02"`,
 			expected: []item{
-				{itemAnd, ""},
-				{itemField, "ts"}, {itemOperator, ">"}, {itemString, `"2019-03-02"`},
-				{itemEOF, ""},
+				{typ: itemAnd, val: ""},
+				{typ: itemField, val: "ts"}, {typ: itemOperator, val: ">"}, {typ: itemString, val: `"2019-03-02"`},
+				{typ: itemEOF, val: ""},
+			},
+		},
+		{
+			query: `foo=3 OR bar=4`,
+			expected: []item{
+				{typ: itemAnd, val: ""},
+				{typ: itemField, val: "foo"}, {typ: itemOperator, val: "="}, {typ: itemNumber, val: "3"},
+				{typ: itemOr, val: "OR"},
+				{typ: itemField, val: "bar"}, {typ: itemOperator, val: "="}, {typ: itemNumber, val: "4"},
+				{typ: itemEOF, val: ""},
+			},
+		},
+		{
+			query: `ORDER=3`,
+			expected: []item{
+				{typ: itemAnd, val: ""},
+				{typ: itemField, val: "ORDER"}, {typ: itemOperator, val: "="}, {typ: itemNumber, val: "3"},
+				{typ: itemEOF, val: ""},
+			},
+		},
+		{
+			query: `(foo=3 OR bar:*) baz=4`,
+			expected: []item{
+				{typ: itemAnd, val: ""},
+				{typ: itemLeftParen, val: "("},
+				{typ: itemField, val: "foo"}, {typ: itemOperator, val: "="}, {typ: itemNumber, val: "3"},
+				{typ: itemOr, val: "OR"},
+				{typ: itemField, val: "bar"}, {typ: itemOperator, val: ":*"},
+				{typ: itemRightParen, val: ")"},
+				{typ: itemField, val: "baz"}, {typ: itemOperator, val: "="}, {typ: itemNumber, val: "4"},
+				{typ: itemEOF, val: ""},
+			},
+		},
+		{
+			query: `((foo=3) )`,
+			expected: []item{
+				{typ: itemAnd, val: ""},
+				{typ: itemLeftParen, val: "("},
+				{typ: itemLeftParen, val: "("},
+				{typ: itemField, val: "foo"}, {typ: itemOperator, val: "="}, {typ: itemNumber, val: "3"},
+				{typ: itemRightParen, val: ")"},
+				{typ: itemRightParen, val: ")"},
+				{typ: itemEOF, val: ""},
+			},
+		},
+		{
+			query: `NOT foo:3`,
+			expected: []item{
+				{typ: itemAnd, val: ""},
+				{typ: itemNot, val: ""},
+				{typ: itemField, val: "foo"}, {typ: itemOperator, val: ":"}, {typ: itemNumber, val: "3"},
+				{typ: itemEOF, val: ""},
+			},
+		},
+		{
+			query: `NOT (foo=3 OR bar=4)`,
+			expected: []item{
+				{typ: itemAnd, val: ""},
+				{typ: itemNot, val: ""},
+				{typ: itemLeftParen, val: "("},
+				{typ: itemField, val: "foo"}, {typ: itemOperator, val: "="}, {typ: itemNumber, val: "3"},
+				{typ: itemOr, val: "OR"},
+				{typ: itemField, val: "bar"}, {typ: itemOperator, val: "="}, {typ: itemNumber, val: "4"},
+				{typ: itemRightParen, val: ")"},
+				{typ: itemEOF, val: ""},
+			},
+		},
+		{
+			query: `-(foo=3)`,
+			expected: []item{
+				{typ: itemAnd, val: ""},
+				{typ: itemNot, val: ""},
+				{typ: itemLeftParen, val: "("},
+				{typ: itemField, val: "foo"}, {typ: itemOperator, val: "="}, {typ: itemNumber, val: "3"},
+				{typ: itemRightParen, val: ")"},
+				{typ: itemEOF, val: ""},
+			},
+		},
+		{
+			query: `NOTE=3 foo=4 AND bar=5`,
+			expected: []item{
+				{typ: itemAnd, val: ""},
+				{typ: itemField, val: "NOTE"}, {typ: itemOperator, val: "="}, {typ: itemNumber, val: "3"},
+				{typ: itemField, val: "foo"}, {typ: itemOperator, val: "="}, {typ: itemNumber, val: "4"},
+				{typ: itemAnd, val: "AND"},
+				{typ: itemField, val: "bar"}, {typ: itemOperator, val: "="}, {typ: itemNumber, val: "5"},
+				{typ: itemEOF, val: ""},
+			},
+		},
+		{
+			query: `id IN (1, 2,3) status IN (A)`,
+			expected: []item{
+				{typ: itemAnd, val: ""},
+				{typ: itemField, val: "id"}, {typ: itemOperator, val: "IN"},
+				{typ: itemLeftParen, val: "("},
+				{typ: itemNumber, val: "1"}, {typ: itemNumber, val: "2"}, {typ: itemNumber, val: "3"},
+				{typ: itemRightParen, val: ")"},
+				{typ: itemField, val: "status"}, {typ: itemOperator, val: "IN"},
+				{typ: itemLeftParen, val: "("},
+				{typ: itemConstant, val: "A"},
+				{typ: itemRightParen, val: ")"},
+				{typ: itemEOF, val: ""},
+			},
+		},
+		{
+			query: `(name IN ("foo", bar))`,
+			expected: []item{
+				{typ: itemAnd, val: ""},
+				{typ: itemLeftParen, val: "("},
+				{typ: itemField, val: "name"}, {typ: itemOperator, val: "IN"},
+				{typ: itemLeftParen, val: "("},
+				{typ: itemString, val: `"foo"`}, {typ: itemConstant, val: "bar"},
+				{typ: itemRightParen, val: ")"},
+				{typ: itemRightParen, val: ")"},
+				{typ: itemEOF, val: ""},
+			},
+		},
+		{
+			query: `a=1.5 b>-2.25 c<=1e10 d=+3.5E-2 e=-7`,
+			expected: []item{
+				{typ: itemAnd, val: ""},
+				{typ: itemField, val: "a"}, {typ: itemOperator, val: "="}, {typ: itemFloat, val: "1.5"},
+				{typ: itemField, val: "b"}, {typ: itemOperator, val: ">"}, {typ: itemFloat, val: "-2.25"},
+				{typ: itemField, val: "c"}, {typ: itemOperator, val: "<="}, {typ: itemFloat, val: "1e10"},
+				{typ: itemField, val: "d"}, {typ: itemOperator, val: "="}, {typ: itemFloat, val: "+3.5E-2"},
+				{typ: itemField, val: "e"}, {typ: itemOperator, val: "="}, {typ: itemNumber, val: "-7"},
+				{typ: itemEOF, val: ""},
 			},
 		},
-		// {
-		//  query: `NOT foo:3`,
-		//  expected: []item{
-		//    {itemEOF, ""},
-		//  },
-		// },
 	}
 	for i, test := range tests {
 		l := lex(test.query)
@@ -155,3 +276,12 @@ func TestLexer(t *testing.T) {
 		}
 	}
 }
+
+func BenchmarkLexer(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l := lex(`status=ACTIVE (id=3 OR id=4) -name:"foo bar" createTime>"2019-03-02T14:15:16Z"`)
+		for l.nextItem().typ != itemEOF { // revive:disable-line:empty-block
+		}
+	}
+}
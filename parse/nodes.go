@@ -2,7 +2,6 @@ package parse
 
 import (
 	"strconv"
-	"strings"
 )
 
 type Node interface {
@@ -24,6 +23,10 @@ const (
 	NodeConstant
 	NodeAnd
 	NodeExpr
+	NodeOr
+	NodeGroup
+	NodeList
+	NodeFloat
 )
 
 type FieldNode struct {
@@ -70,6 +73,18 @@ func (n *NumberNode) String() string {
 	return strconv.FormatInt(n.Val, 10)
 }
 
+type FloatNode struct {
+	NodeType
+	Val float64
+
+	// Texto original del número para poder conservar la precisión exacta.
+	Text string
+}
+
+func (f *FloatNode) String() string {
+	return f.Text
+}
+
 type ConstantNode struct {
 	NodeType
 	Name string
@@ -79,17 +94,41 @@ func (c *ConstantNode) String() string {
 	return c.Name
 }
 
+type ListNode struct {
+	NodeType
+	Vals []Node
+}
+
+func (l *ListNode) String() string {
+	return Format(l)
+}
+
 type AndNode struct {
 	NodeType
-	Nodes []*ExprNode
+	Nodes []Node
 }
 
 func (a *AndNode) String() string {
-	s := make([]string, len(a.Nodes))
-	for i, node := range a.Nodes {
-		s[i] = node.String()
-	}
-	return strings.Join(s, " ")
+	return Format(a)
+}
+
+type OrNode struct {
+	NodeType
+	Nodes []Node
+}
+
+func (o *OrNode) String() string {
+	return Format(o)
+}
+
+type GroupNode struct {
+	NodeType
+	Expr     *AndNode
+	Negative bool
+}
+
+func (g *GroupNode) String() string {
+	return Format(g)
 }
 
 type ExprNode struct {
@@ -100,10 +139,6 @@ type ExprNode struct {
 	Negative bool
 }
 
-func (e ExprNode) String() string {
-	s := e.Field.String() + e.Op.String() + e.Val.String()
-	if e.Negative {
-		return "NOT " + s
-	}
-	return s
+func (e *ExprNode) String() string {
+	return Format(e)
 }
@@ -6,6 +6,16 @@ func (op Operator) HasArg() bool {
 	return op != OpExists
 }
 
+// Valid indica si el operador es uno de los que reconoce el parser.
+func (op Operator) Valid() bool {
+	for _, o := range allOperators {
+		if o == op {
+			return true
+		}
+	}
+	return false
+}
+
 const (
 	OpEqual              = Operator("=")
 	OpNotEqual           = Operator("!=")
@@ -15,6 +25,7 @@ const (
 	OpGreaterOrEqualThan = Operator(">=")
 	OpLessThan           = Operator("<")
 	OpLessOrEqualThan    = Operator("<=")
+	OpIn                 = Operator("IN")
 )
 
 var allOperators = []Operator{
@@ -26,4 +37,5 @@ var allOperators = []Operator{
 	OpGreaterOrEqualThan,
 	OpLessThan,
 	OpLessOrEqualThan,
+	OpIn,
 }
@@ -1,10 +1,9 @@
 package parse
 
 import (
+	"fmt"
 	"runtime"
 	"strconv"
-
-	"libs.altipla.consulting/errors"
 )
 
 type parser struct {
@@ -23,7 +22,7 @@ func (p *parser) next() item {
 	if p.peekCount > 0 {
 		p.peekCount--
 	} else {
-		p.token[0] = p.lex.nextItem()
+		p.token[0] = p.nextItem()
 	}
 	return p.token[p.peekCount]
 }
@@ -37,16 +36,26 @@ func (p *parser) peek() item {
 		return p.token[p.peekCount-1]
 	}
 	p.peekCount = 1
-	p.token[0] = p.lex.nextItem()
+	p.token[0] = p.nextItem()
 	return p.token[0]
 }
 
-func (p *parser) errorf(format string, args ...interface{}) {
-	panic(errors.Errorf(format, args...))
+// nextItem lee el siguiente token del lexer y aborta directamente si es un
+// error, que ya viene con su posición desde el propio lexer.
+func (p *parser) nextItem() item {
+	tok := p.lex.nextItem()
+	if tok.typ == itemError {
+		panic(p.lex.err)
+	}
+	return tok
+}
+
+func (p *parser) errorf(token item, expected []string, format string, args ...interface{}) {
+	panic(newSyntaxError(p.lex.input, token.pos, token.val, expected, fmt.Sprintf(format, args...)))
 }
 
-func (p *parser) unexpected(token item, context string) {
-	p.errorf("unexpected %s in %s", token, context)
+func (p *parser) unexpected(token item, context string, expected ...string) {
+	p.errorf(token, expected, "unexpected %s in %s", token, context)
 }
 
 func (p *parser) recover(errp *error) {
@@ -55,29 +64,90 @@ func (p *parser) recover(errp *error) {
 		if _, ok := e.(runtime.Error); ok {
 			panic(e)
 		}
-		if p != nil {
-			p.lex.drain()
-		}
 		*errp = e.(error)
 	}
 }
 
 func (p *parser) parseAnd() *AndNode {
-	n := &AndNode{
-		NodeType: NodeAnd,
-	}
-
 	switch next := p.next(); next.typ {
 	case itemAnd:
 	case itemEOF:
-		return n
+		return &AndNode{
+			NodeType: NodeAnd,
+		}
 	default:
-		p.unexpected(next, "AND")
+		p.unexpected(next, "AND", "AND", "EOF")
+	}
+
+	return p.parseSequence(itemEOF)
+}
+
+// parseSequence lee términos unidos implícitamente con AND hasta encontrar
+// el token que cierra la secuencia, que se deja sin consumir.
+func (p *parser) parseSequence(end itemType) *AndNode {
+	n := &AndNode{
+		NodeType: NodeAnd,
+	}
+	for {
+		switch tok := p.peek(); tok.typ {
+		case end:
+			if end == itemRightParen && len(n.Nodes) == 0 {
+				p.unexpected(tok, "group", "field", "(")
+			}
+			return n
+		case itemEOF:
+			p.unexpected(tok, "group", ")")
+		case itemRightParen:
+			p.unexpected(tok, "expression", "field", "(", "EOF")
+		}
+
+		n.Nodes = append(n.Nodes, p.parseOr())
+
+		// El AND explícito es equivalente a separar los términos con espacios.
+		if p.peek().typ == itemAnd {
+			p.next()
+		}
+	}
+}
+
+// parseOr lee una lista de términos separados por OR. Siguiendo AIP-160 el OR
+// tiene más prioridad que el AND, por lo que siempre agrupa términos simples.
+func (p *parser) parseOr() Node {
+	first := p.parseTerm()
+	if p.peek().typ != itemOr {
+		return first
+	}
+
+	n := &OrNode{
+		NodeType: NodeOr,
+		Nodes:    []Node{first},
+	}
+	for p.peek().typ == itemOr {
+		p.next()
+		n.Nodes = append(n.Nodes, p.parseTerm())
+	}
+	return n
+}
+
+func (p *parser) parseTerm() Node {
+	// Tanto las comparaciones como los grupos pueden ir negados delante.
+	var negative bool
+	if p.peek().typ == itemNot {
+		p.next()
+		negative = true
+	}
+
+	if p.peek().typ != itemLeftParen {
+		return p.parseExpr(negative)
 	}
+	p.next()
 
-	for p.peek().typ != itemEOF {
-		n.Nodes = append(n.Nodes, p.parseExpr())
+	n := &GroupNode{
+		NodeType: NodeGroup,
+		Expr:     p.parseSequence(itemRightParen),
+		Negative: negative,
 	}
+	p.next()
 
 	return n
 }
@@ -85,7 +155,7 @@ func (p *parser) parseAnd() *AndNode {
 func (p *parser) parseOperator() *OperatorNode {
 	tok := p.next()
 	if tok.typ != itemOperator {
-		p.unexpected(tok, "expression operator")
+		p.unexpected(tok, "expression operator", "operator")
 	}
 
 	for _, op := range allOperators {
@@ -97,22 +167,15 @@ func (p *parser) parseOperator() *OperatorNode {
 		}
 	}
 
-	p.errorf("unknown operator: %v", tok.val)
+	p.errorf(tok, []string{"operator"}, "unknown operator: %v", tok.val)
 
 	panic("should not reach here")
 }
 
-func (p *parser) parseExpr() *ExprNode {
-	// Lee el nombre del campo, posiblemente con una negativa delante.
-	var negative bool
+func (p *parser) parseExpr(negative bool) *ExprNode {
 	tok := p.next()
-	switch tok.typ {
-	case itemField:
-	case itemNot:
-		negative = true
-		tok = p.next()
-	default:
-		p.unexpected(tok, "expression field")
+	if tok.typ != itemField {
+		p.unexpected(tok, "expression field", "field")
 	}
 
 	expr := &ExprNode{
@@ -130,34 +193,74 @@ func (p *parser) parseExpr() *ExprNode {
 		return expr
 	}
 
-	// Operadores con argumentos de varios posibles tipos. Aquí no se comprueba
-	// el tipo, solamente se lee lo que haya y se guarda en la expresión.
+	if expr.Op.Val == OpIn {
+		expr.Val = p.parseList()
+		return expr
+	}
+
+	expr.Val = p.parseValue()
+
+	return expr
+}
+
+// parseList lee la lista de valores entre paréntesis de los operadores de
+// conjuntos.
+func (p *parser) parseList() *ListNode {
+	if tok := p.next(); tok.typ != itemLeftParen {
+		p.unexpected(tok, "list of values", "(")
+	}
+
+	n := &ListNode{
+		NodeType: NodeList,
+	}
+	for p.peek().typ != itemRightParen {
+		n.Vals = append(n.Vals, p.parseValue())
+	}
+	p.next()
+
+	return n
+}
+
+// parseValue lee un argumento de varios posibles tipos. Aquí no se comprueba
+// el tipo, solamente se lee lo que haya y se guarda en la expresión.
+func (p *parser) parseValue() Node {
 	switch tok := p.next(); tok.typ {
 	case itemNumber:
 		val, err := strconv.ParseInt(tok.val, 10, 64)
 		if err != nil {
-			p.errorf("cannot parse number: %v: %s", tok.val, err)
+			p.errorf(tok, []string{"number"}, "cannot parse number: %v: %s", tok.val, err)
 		}
-		expr.Val = &NumberNode{
+		return &NumberNode{
 			NodeType: NodeNumber,
 			Val:      val,
 		}
 
+	case itemFloat:
+		val, err := strconv.ParseFloat(tok.val, 64)
+		if err != nil {
+			p.errorf(tok, []string{"number"}, "cannot parse number: %v: %s", tok.val, err)
+		}
+		return &FloatNode{
+			NodeType: NodeFloat,
+			Val:      val,
+			Text:     tok.val,
+		}
+
 	case itemString:
-		expr.Val = &StringNode{
+		return &StringNode{
 			NodeType: NodeString,
 			Quoted:   tok.val,
 		}
 
 	case itemConstant:
-		expr.Val = &ConstantNode{
+		return &ConstantNode{
 			NodeType: NodeConstant,
 			Name:     tok.val,
 		}
 
 	default:
-		p.unexpected(tok, "expression value")
+		p.unexpected(tok, "expression value", "string", "number", "value")
 	}
 
-	return expr
+	panic("should not reach here")
 }
@@ -0,0 +1,294 @@
+package parse
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		{
+			query:    ``,
+			expected: ``,
+		},
+		{
+			query:    `foo=3 bar:*`,
+			expected: `foo=3 bar:*`,
+		},
+		{
+			query:    `foo=3 OR bar=4`,
+			expected: `foo=3 OR bar=4`,
+		},
+		{
+			query:    `foo=3 OR bar=4 baz=5`,
+			expected: `foo=3 OR bar=4 baz=5`,
+		},
+		{
+			query:    `(foo=3 OR bar=4) baz=5`,
+			expected: `(foo=3 OR bar=4) baz=5`,
+		},
+		{
+			query:    `foo=3 OR (bar=4 baz=5)`,
+			expected: `foo=3 OR (bar=4 baz=5)`,
+		},
+		{
+			query:    `((foo=3))`,
+			expected: `((foo=3))`,
+		},
+		{
+			query:    `NOT foo=3`,
+			expected: `-foo=3`,
+		},
+		{
+			query:    `-foo=3`,
+			expected: `-foo=3`,
+		},
+		{
+			query:    `NOT (foo=3 OR bar=4)`,
+			expected: `NOT (foo=3 OR bar=4)`,
+		},
+		{
+			query:    `-(foo=3) bar=4`,
+			expected: `NOT (foo=3) bar=4`,
+		},
+		{
+			query:    `foo=3 AND bar=4`,
+			expected: `foo=3 bar=4`,
+		},
+		{
+			query:    `id IN (1,2, 3)`,
+			expected: `id IN (1, 2, 3)`,
+		},
+		{
+			query:    `NOT status IN (ACTIVE, "PENDING") OR id=3`,
+			expected: `-status IN (ACTIVE, "PENDING") OR id=3`,
+		},
+		{
+			query:    `price>=10.50 rating<4e-1 delta=-3`,
+			expected: `price>=10.50 rating<4e-1 delta=-3`,
+		},
+		{
+			query:    `foo=3 AND bar=4 OR baz=5 AND NOT qux:*`,
+			expected: `foo=3 bar=4 OR baz=5 -qux:*`,
+		},
+	}
+	for i, test := range tests {
+		root, err := Parse(test.query)
+		require.NoError(t, err, "test %v: [%v]", i, test.query)
+		require.Equal(t, test.expected, root.String(), "test %v: [%v]", i, test.query)
+	}
+}
+
+func TestParseOrPrecedence(t *testing.T) {
+	root, err := Parse(`a=1 OR b=2 c=3`)
+	require.NoError(t, err)
+
+	require.Len(t, root.Nodes, 2)
+	or, ok := root.Nodes[0].(*OrNode)
+	require.True(t, ok)
+	require.Len(t, or.Nodes, 2)
+	require.Equal(t, "a=1", or.Nodes[0].String())
+	require.Equal(t, "b=2", or.Nodes[1].String())
+	require.Equal(t, "c=3", root.Nodes[1].String())
+}
+
+func TestParseGroup(t *testing.T) {
+	root, err := Parse(`(a=1 OR b=2) c=3`)
+	require.NoError(t, err)
+
+	require.Len(t, root.Nodes, 2)
+	group, ok := root.Nodes[0].(*GroupNode)
+	require.True(t, ok)
+	require.Len(t, group.Expr.Nodes, 1)
+	require.IsType(t, &OrNode{}, group.Expr.Nodes[0])
+}
+
+var invalidQueries = []string{
+	`(foo=3`,
+	`foo=3)`,
+	`()`,
+	`foo=3 OR`,
+	`OR foo=3`,
+	`(foo=3))`,
+	`foo=3 AND`,
+	`AND foo=3`,
+	`NOT`,
+	`foo=3 NOT`,
+	`NOT NOT foo=3`,
+	`id IN ()`,
+	`id IN (1 2)`,
+	`id IN (1,)`,
+	`id IN (1`,
+	`id IN 1`,
+	`id IN (1) (2)`,
+	`price=1.`,
+	`price=1.5.3`,
+	`price=1e`,
+	`price=1e+`,
+	`price=.5`,
+	`price=1e400`,
+	`foo="\q"`,
+	`foo="\x4"`,
+	`AND=3`,
+	`NOT=3`,
+	`IN:*`,
+	`a=1 AND AND IN (1)`,
+	`a=1 AND OR IN (1)`,
+	`a=1 OR NOT IN (1)`,
+	`NOT IN (1)`,
+	`(OR=1)`,
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, test := range invalidQueries {
+		_, err := Parse(test)
+		require.Error(t, err, "query: [%v]", test)
+	}
+}
+
+func TestParseFloat(t *testing.T) {
+	root, err := Parse(`price=-1.25e2`)
+	require.NoError(t, err)
+
+	val, ok := root.Nodes[0].(*ExprNode).Val.(*FloatNode)
+	require.True(t, ok)
+	require.Equal(t, -125.0, val.Val)
+	require.Equal(t, "-1.25e2", val.Text)
+}
+
+func TestParseSyntaxError(t *testing.T) {
+	tests := []struct {
+		query    string
+		offset   int
+		token    string
+		expected []string
+	}{
+		{
+			query:    `foo=3 bar`,
+			offset:   9,
+			token:    ``,
+			expected: []string{"operator"},
+		},
+		{
+			query:    `foo=3 bar=)`,
+			offset:   10,
+			token:    `)`,
+			expected: []string{"value"},
+		},
+		{
+			query:    `foo=3 =4`,
+			offset:   6,
+			token:    `=`,
+			expected: []string{"field", "("},
+		},
+		{
+			query:    `foo="bar`,
+			offset:   4,
+			token:    `"bar`,
+			expected: []string{`"`},
+		},
+		{
+			query:    `(foo=3`,
+			offset:   6,
+			token:    ``,
+			expected: []string{")"},
+		},
+		{
+			query:    `foo=3)`,
+			offset:   5,
+			token:    `)`,
+			expected: []string{"field", "(", "EOF"},
+		},
+		{
+			query:    `foo=3 bar=>4`,
+			offset:   9,
+			token:    `=>`,
+			expected: []string{"operator"},
+		},
+		{
+			query:    `a=1 AND AND IN (1)`,
+			offset:   8,
+			token:    `AND`,
+			expected: []string{"field", "("},
+		},
+		{
+			query:    `foo=3;`,
+			offset:   5,
+			token:    `;`,
+			expected: []string{"space", ")", "EOF"},
+		},
+	}
+	for i, test := range tests {
+		_, err := Parse(test.query)
+		require.Error(t, err, "test %v: [%v]", i, test.query)
+
+		serr, ok := err.(*SyntaxError)
+		require.True(t, ok, "test %v: [%v]: %T", i, test.query, err)
+		require.Equal(t, test.offset, serr.Offset, "test %v: [%v]: %v", i, test.query, err)
+		require.Equal(t, 1, serr.Line, "test %v: [%v]", i, test.query)
+		require.Equal(t, test.offset+1, serr.Column, "test %v: [%v]", i, test.query)
+		require.Equal(t, test.token, serr.Token, "test %v: [%v]", i, test.query)
+		require.Equal(t, test.expected, serr.Expected, "test %v: [%v]", i, test.query)
+	}
+}
+
+func TestSyntaxErrorCaret(t *testing.T) {
+	_, err := Parse(`foo=3 bar=)`)
+	require.Error(t, err)
+
+	require.EqualError(t, err, `1:11: unknown constant: ")"`)
+	require.Equal(t, "foo=3 bar=)\n          ^", err.(*SyntaxError).Caret())
+}
+
+func TestSyntaxErrorColumnRunes(t *testing.T) {
+	_, err := Parse(`name="ñandú" ñ=3`)
+	require.Error(t, err)
+
+	serr := err.(*SyntaxError)
+	require.Equal(t, 15, serr.Offset)
+	require.Equal(t, 14, serr.Column)
+	require.Equal(t, "ñ", serr.Token)
+}
+
+func TestParseDoesNotLeakGoroutines(t *testing.T) {
+	before := runtime.NumGoroutine()
+	for i := 0; i < 100; i++ {
+		_, err := Parse(`foo=3 bar=)`)
+		require.Error(t, err)
+		_, err = Parse(`foo=3 (bar=4 OR baz:*)`)
+		require.NoError(t, err)
+	}
+	require.Equal(t, before, runtime.NumGoroutine())
+}
+
+func BenchmarkParse(b *testing.B) {
+	queries := []string{
+		`id=3`,
+		`status=ACTIVE createTime>"2019-03-02T14:15:16Z"`,
+		`status=ACTIVE (id=3 OR id=4) -name:"foo bar" createTime>"2019-03-02T14:15:16Z"`,
+	}
+	for _, query := range queries {
+		b.Run(query, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := Parse(query); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkParseError(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := Parse(`status=ACTIVE (id=3 OR id=4) -name:"foo bar`); err == nil {
+			b.Fatal("expected error")
+		}
+	}
+}
@@ -0,0 +1,341 @@
+package parse
+
+import (
+	"fmt"
+)
+
+// Visitor recibe cada nodo que encuentra Walk. Si devuelve un visitor distinto
+// de nil se usa para recorrer los hijos del nodo y al acabar se le llama con
+// nil.
+type Visitor interface {
+	Visit(node Node) (w Visitor)
+}
+
+// Walk recorre el árbol en profundidad empezando por node. Los hijos de una
+// comparación se visitan en orden: campo, operador y valor.
+func Walk(v Visitor, node Node) {
+	if v = v.Visit(node); v == nil {
+		return
+	}
+
+	switch n := node.(type) {
+	case *AndNode:
+		walkList(v, n.Nodes)
+	case *OrNode:
+		walkList(v, n.Nodes)
+	case *GroupNode:
+		if n.Expr != nil {
+			Walk(v, n.Expr)
+		}
+	case *ExprNode:
+		if n.Field != nil {
+			Walk(v, n.Field)
+		}
+		if n.Op != nil {
+			Walk(v, n.Op)
+		}
+		if n.Val != nil {
+			Walk(v, n.Val)
+		}
+	case *ListNode:
+		walkList(v, n.Vals)
+	}
+
+	v.Visit(nil)
+}
+
+func walkList(v Visitor, nodes []Node) {
+	for _, node := range nodes {
+		Walk(v, node)
+	}
+}
+
+type inspector func(Node) bool
+
+func (f inspector) Visit(node Node) Visitor {
+	if f(node) {
+		return f
+	}
+	return nil
+}
+
+// Inspect recorre el árbol llamando a f con cada nodo. Si f devuelve false no
+// se recorren los hijos de ese nodo. Al acabar con los hijos de un nodo se
+// llama a f con nil.
+func Inspect(node Node, f func(Node) bool) {
+	Walk(inspector(f), node)
+}
+
+// RewriteFunc recibe un nodo del árbol y devuelve el que lo sustituye. Puede
+// devolver el mismo nodo para dejarlo como está o nil para eliminarlo.
+type RewriteFunc func(node Node) Node
+
+// Rewrite recorre el árbol en profundidad y devuelve una copia transformada.
+// Antes de bajar a los hijos de un nodo se llama a pre y se recorren los hijos
+// del nodo que devuelva; al acabar con ellos se llama a post. Cualquiera de las
+// dos funciones puede ser nil. El árbol original no se modifica: las funciones
+// reciben nodos de una copia completa y los pueden cambiar directamente.
+//
+// Después de cada cambio el árbol se corrige para que siga siendo válido:
+//   - Una secuencia que sustituye a un nodo de otra secuencia se une a ella.
+//   - Una secuencia que sustituye a un término de OR se mete en un grupo.
+//   - Un OR que se queda con un solo término se sustituye por él.
+//   - Los OR, grupos y listas que se quedan vacíos se eliminan.
+//   - Las comparaciones que se quedan sin campo, operador o valor se eliminan.
+//
+// Si se sustituye un nodo por otro de un tipo que no puede ocupar su posición
+// la función entra en pánico.
+func Rewrite(node Node, pre, post RewriteFunc) Node {
+	r := &rewriter{pre: pre, post: post}
+	return r.rewrite(cloneNode(node))
+}
+
+// cloneNode copia el nodo y todos sus hijos.
+func cloneNode(node Node) Node {
+	switch n := node.(type) {
+	case *AndNode:
+		c := *n
+		c.Nodes = cloneNodes(n.Nodes)
+		return &c
+	case *OrNode:
+		c := *n
+		c.Nodes = cloneNodes(n.Nodes)
+		return &c
+	case *GroupNode:
+		c := *n
+		if n.Expr != nil {
+			c.Expr = cloneNode(n.Expr).(*AndNode)
+		}
+		return &c
+	case *ExprNode:
+		c := *n
+		if n.Field != nil {
+			c.Field = cloneNode(n.Field).(*FieldNode)
+		}
+		if n.Op != nil {
+			c.Op = cloneNode(n.Op).(*OperatorNode)
+		}
+		if n.Val != nil {
+			c.Val = cloneNode(n.Val)
+		}
+		return &c
+	case *ListNode:
+		c := *n
+		c.Vals = cloneNodes(n.Vals)
+		return &c
+	case *FieldNode:
+		c := *n
+		return &c
+	case *OperatorNode:
+		c := *n
+		return &c
+	case *StringNode:
+		c := *n
+		return &c
+	case *NumberNode:
+		c := *n
+		return &c
+	case *FloatNode:
+		c := *n
+		return &c
+	case *ConstantNode:
+		c := *n
+		return &c
+	}
+	return node
+}
+
+func cloneNodes(nodes []Node) []Node {
+	if nodes == nil {
+		return nil
+	}
+	cloned := make([]Node, len(nodes))
+	for i, node := range nodes {
+		cloned[i] = cloneNode(node)
+	}
+	return cloned
+}
+
+type rewriter struct {
+	pre, post RewriteFunc
+}
+
+func (r *rewriter) rewrite(node Node) Node {
+	if r.pre != nil {
+		if node = r.pre(node); node == nil {
+			return nil
+		}
+	}
+
+	switch n := node.(type) {
+	case *AndNode:
+		node = r.rewriteAnd(n)
+	case *OrNode:
+		node = r.rewriteOr(n)
+	case *GroupNode:
+		node = r.rewriteGroup(n)
+	case *ExprNode:
+		node = r.rewriteExpr(n)
+	case *ListNode:
+		node = r.rewriteList(n)
+	}
+	if node == nil {
+		return nil
+	}
+
+	if r.post != nil {
+		node = r.post(node)
+	}
+	return node
+}
+
+func (r *rewriter) rewriteAnd(n *AndNode) Node {
+	and := &AndNode{
+		NodeType: NodeAnd,
+	}
+	for _, child := range n.Nodes {
+		switch child := r.rewrite(child).(type) {
+		case nil:
+		case *AndNode:
+			and.Nodes = append(and.Nodes, child.Nodes...)
+		case *OrNode, *GroupNode, *ExprNode:
+			and.Nodes = append(and.Nodes, child)
+		default:
+			panic(fmt.Sprintf("invalid node in rewrite, cannot use %T inside a sequence of conditions", child))
+		}
+	}
+	return and
+}
+
+func (r *rewriter) rewriteOr(n *OrNode) Node {
+	or := &OrNode{
+		NodeType: NodeOr,
+	}
+	for _, child := range n.Nodes {
+		switch child := r.rewrite(child).(type) {
+		case nil:
+		case *OrNode:
+			or.Nodes = append(or.Nodes, child.Nodes...)
+		case *AndNode:
+			// OR tiene más prioridad que AND en la gramática.
+			if len(child.Nodes) == 1 {
+				or.Nodes = append(or.Nodes, rewriteTerm(child.Nodes[0]))
+			} else if len(child.Nodes) > 1 {
+				or.Nodes = append(or.Nodes, &GroupNode{NodeType: NodeGroup, Expr: child})
+			}
+		case *GroupNode, *ExprNode:
+			or.Nodes = append(or.Nodes, child)
+		default:
+			panic(fmt.Sprintf("invalid node in rewrite, cannot use %T as a term of OR", child))
+		}
+	}
+
+	switch len(or.Nodes) {
+	case 0:
+		return nil
+	case 1:
+		return or.Nodes[0]
+	}
+	return or
+}
+
+func rewriteTerm(node Node) Node {
+	if _, ok := node.(*OrNode); ok {
+		return &GroupNode{
+			NodeType: NodeGroup,
+			Expr: &AndNode{
+				NodeType: NodeAnd,
+				Nodes:    []Node{node},
+			},
+		}
+	}
+	return node
+}
+
+func (r *rewriter) rewriteGroup(n *GroupNode) Node {
+	if n.Expr == nil {
+		return nil
+	}
+	expr := r.rewrite(n.Expr)
+	if expr == nil {
+		return nil
+	}
+	seq, ok := expr.(*AndNode)
+	if !ok {
+		seq = &AndNode{
+			NodeType: NodeAnd,
+			Nodes:    []Node{expr},
+		}
+	}
+	if len(seq.Nodes) == 0 {
+		return nil
+	}
+	return &GroupNode{
+		NodeType: NodeGroup,
+		Expr:     seq,
+		Negative: n.Negative,
+	}
+}
+
+func (r *rewriter) rewriteExpr(n *ExprNode) Node {
+	if n.Field == nil || n.Op == nil {
+		return nil
+	}
+	expr := &ExprNode{
+		NodeType: NodeExpr,
+		Negative: n.Negative,
+	}
+
+	field := r.rewrite(n.Field)
+	if field == nil {
+		return nil
+	}
+	f, ok := field.(*FieldNode)
+	if !ok {
+		panic(fmt.Sprintf("invalid node in rewrite, cannot use %T as the field of a comparison", field))
+	}
+	expr.Field = f
+
+	op := r.rewrite(n.Op)
+	if op == nil {
+		return nil
+	}
+	o, ok := op.(*OperatorNode)
+	if !ok {
+		panic(fmt.Sprintf("invalid node in rewrite, cannot use %T as the operator of a comparison", op))
+	}
+	expr.Op = o
+
+	if n.Val != nil {
+		expr.Val = r.rewrite(n.Val)
+		if expr.Val == nil {
+			return nil
+		}
+		switch expr.Val.(type) {
+		case *AndNode, *OrNode, *GroupNode, *ExprNode, *FieldNode, *OperatorNode:
+			panic(fmt.Sprintf("invalid node in rewrite, cannot use %T as the value of a comparison", expr.Val))
+		}
+	}
+
+	return expr
+}
+
+func (r *rewriter) rewriteList(n *ListNode) Node {
+	list := &ListNode{
+		NodeType: NodeList,
+	}
+	for _, val := range n.Vals {
+		val = r.rewrite(val)
+		switch val.(type) {
+		case nil:
+		case *StringNode, *NumberNode, *FloatNode, *ConstantNode:
+			list.Vals = append(list.Vals, val)
+		default:
+			panic(fmt.Sprintf("invalid node in rewrite, cannot use %T inside a list of values", val))
+		}
+	}
+	if len(list.Vals) == 0 {
+		return nil
+	}
+	return list
+}
@@ -0,0 +1,262 @@
+package parse
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInspect(t *testing.T) {
+	root, err := Parse(`foo=3 (bar:* OR -baz IN (1, "a")) qux="x"`)
+	require.NoError(t, err)
+
+	var types []NodeType
+	var fields []string
+	Inspect(root, func(node Node) bool {
+		if node == nil {
+			return false
+		}
+		types = append(types, node.Type())
+		if field, ok := node.(*FieldNode); ok {
+			fields = append(fields, field.Name)
+		}
+		return true
+	})
+
+	require.Equal(t, []string{"foo", "bar", "baz", "qux"}, fields)
+	require.Equal(t, []NodeType{
+		NodeAnd,
+		NodeExpr, NodeField, NodeOperator, NodeNumber,
+		NodeGroup, NodeAnd, NodeOr,
+		NodeExpr, NodeField, NodeOperator,
+		NodeExpr, NodeField, NodeOperator, NodeList, NodeNumber, NodeString,
+		NodeExpr, NodeField, NodeOperator, NodeString,
+	}, types)
+}
+
+func TestInspectSkipChildren(t *testing.T) {
+	root, err := Parse(`foo=3 (bar=4 OR baz=5)`)
+	require.NoError(t, err)
+
+	var fields []string
+	Inspect(root, func(node Node) bool {
+		switch node := node.(type) {
+		case *GroupNode:
+			return false
+		case *FieldNode:
+			fields = append(fields, node.Name)
+		}
+		return true
+	})
+	require.Equal(t, []string{"foo"}, fields)
+}
+
+type depthVisitor struct {
+	depth int
+	max   *int
+}
+
+func (v depthVisitor) Visit(node Node) Visitor {
+	if node == nil {
+		return nil
+	}
+	if v.depth > *v.max {
+		*v.max = v.depth
+	}
+	return depthVisitor{depth: v.depth + 1, max: v.max}
+}
+
+func TestWalk(t *testing.T) {
+	root, err := Parse(`foo=3 ((bar=4))`)
+	require.NoError(t, err)
+
+	var max int
+	Walk(depthVisitor{max: &max}, root)
+	require.Equal(t, 6, max)
+}
+
+func TestRewrite(t *testing.T) {
+	tests := []struct {
+		query    string
+		pre      RewriteFunc
+		post     RewriteFunc
+		expected string
+	}{
+		{
+			query: `foo=3 bar:* foo.baz="a"`,
+			pre: func(node Node) Node {
+				if field, ok := node.(*FieldNode); ok && strings.HasPrefix(field.Name, "foo") {
+					return &FieldNode{NodeType: NodeField, Name: "qux" + strings.TrimPrefix(field.Name, "foo")}
+				}
+				return node
+			},
+			expected: `qux=3 bar:* qux.baz="a"`,
+		},
+		{
+			query:    `foo=3 (bar=4 OR baz=5) -qux:*`,
+			pre:      removeField("bar"),
+			expected: `foo=3 (baz=5) -qux:*`,
+		},
+		{
+			query:    `foo=3 OR bar=4`,
+			pre:      removeField("bar"),
+			expected: `foo=3`,
+		},
+		{
+			query:    `foo=3 (bar=4) NOT (bar=5 bar=6)`,
+			pre:      removeField("bar"),
+			expected: `foo=3`,
+		},
+		{
+			query: `foo IN (1, 2, 3) bar IN (4)`,
+			pre: func(node Node) Node {
+				if n, ok := node.(*NumberNode); ok && n.Val%2 == 0 {
+					return nil
+				}
+				return node
+			},
+			expected: `foo IN (1, 3)`,
+		},
+		{
+			query: `foo=3 bar=4`,
+			post: func(node Node) Node {
+				if expr, ok := node.(*ExprNode); ok && expr.Field.Name == "bar" {
+					return &AndNode{
+						NodeType: NodeAnd,
+						Nodes:    []Node{expr, mustParseExpr(t, `tenant="acme"`)},
+					}
+				}
+				return node
+			},
+			expected: `foo=3 bar=4 tenant="acme"`,
+		},
+		{
+			query: `foo=3 OR bar=4`,
+			post: func(node Node) Node {
+				if expr, ok := node.(*ExprNode); ok && expr.Field.Name == "bar" {
+					return &AndNode{
+						NodeType: NodeAnd,
+						Nodes:    []Node{expr, mustParseExpr(t, `tenant="acme"`)},
+					}
+				}
+				return node
+			},
+			expected: `foo=3 OR (bar=4 tenant="acme")`,
+		},
+		{
+			query: `foo=3 bar=4`,
+			post: func(node Node) Node {
+				if expr, ok := node.(*ExprNode); ok && expr.Field.Name == "bar" {
+					root, err := Parse(`baz=5 OR qux=6`)
+					require.NoError(t, err)
+					return root.Nodes[0]
+				}
+				return node
+			},
+			expected: `foo=3 baz=5 OR qux=6`,
+		},
+		{
+			query: `foo=3 -bar=4`,
+			post: func(node Node) Node {
+				if root, ok := node.(*AndNode); ok {
+					return &AndNode{
+						NodeType: NodeAnd,
+						Nodes:    append([]Node{mustParseExpr(t, `tenant="acme"`)}, root.Nodes...),
+					}
+				}
+				return node
+			},
+			expected: `tenant="acme" foo=3 -bar=4`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			root, err := Parse(test.query)
+			require.NoError(t, err)
+
+			rewritten := Rewrite(root, test.pre, test.post)
+			require.Equal(t, test.expected, Format(rewritten))
+
+			again, err := Parse(Format(rewritten))
+			require.NoError(t, err)
+			require.Equal(t, Format(rewritten), Format(again))
+
+			// El árbol original no debe cambiar.
+			original, err := Parse(test.query)
+			require.NoError(t, err)
+			require.Equal(t, Format(original), Format(root))
+		})
+	}
+}
+
+func TestRewriteMutateNodes(t *testing.T) {
+	root, err := Parse(`foo=3 (bar:"a" OR baz IN (1, 2))`)
+	require.NoError(t, err)
+
+	mutate := func(node Node) Node {
+		switch n := node.(type) {
+		case *FieldNode:
+			n.Name = "x" + n.Name
+		case *OperatorNode:
+			if n.Val == OpEqual {
+				n.Val = OpNotEqual
+			}
+		case *StringNode:
+			n.Quoted = `"b"`
+		case *NumberNode:
+			n.Val++
+		case *ListNode:
+			n.Vals = n.Vals[:1]
+		case *AndNode:
+			n.Nodes = append(n.Nodes, mustParseExpr(t, `qux=1`))
+		}
+		return node
+	}
+
+	rewritten := Rewrite(root, mutate, nil)
+	require.Equal(t, `xfoo!=4 (xbar:"b" OR xbaz IN (2) xqux!=2) xqux!=2`, Format(rewritten))
+	require.Equal(t, `foo=3 (bar:"a" OR baz IN (1, 2))`, Format(root))
+
+	rewritten = Rewrite(root, nil, mutate)
+	require.Equal(t, `xfoo!=4 (xbar:"b" OR xbaz IN (2) qux=1) qux=1`, Format(rewritten))
+	require.Equal(t, `foo=3 (bar:"a" OR baz IN (1, 2))`, Format(root))
+}
+
+func TestRewriteInvalidReplacement(t *testing.T) {
+	root, err := Parse(`foo=3`)
+	require.NoError(t, err)
+
+	require.PanicsWithValue(t, "invalid node in rewrite, cannot use *parse.StringNode as the field of a comparison", func() {
+		Rewrite(root, func(node Node) Node {
+			if _, ok := node.(*FieldNode); ok {
+				return &StringNode{NodeType: NodeString, Quoted: `"foo"`}
+			}
+			return node
+		}, nil)
+	})
+	require.PanicsWithValue(t, "invalid node in rewrite, cannot use *parse.NumberNode inside a sequence of conditions", func() {
+		Rewrite(root, func(node Node) Node {
+			if _, ok := node.(*ExprNode); ok {
+				return &NumberNode{NodeType: NodeNumber, Val: 3}
+			}
+			return node
+		}, nil)
+	})
+}
+
+func removeField(name string) RewriteFunc {
+	return func(node Node) Node {
+		if expr, ok := node.(*ExprNode); ok && expr.Field.Name == name {
+			return nil
+		}
+		return node
+	}
+}
+
+func mustParseExpr(t *testing.T, query string) Node {
+	root, err := Parse(query)
+	require.NoError(t, err)
+	require.Len(t, root.Nodes, 1)
+	return root.Nodes[0]
+}
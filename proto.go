@@ -0,0 +1,134 @@
+package expr
+
+import (
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"libs.altipla.consulting/errors"
+)
+
+// ProtoMatcher comprueba la query contra un mensaje protobuf. Los campos se
+// buscan por su nombre JSON o por el nombre del proto y se puede acceder a
+// mensajes anidados con nombres separados por puntos. Los campos repetidos
+// cumplen la condición si cualquiera de sus elementos la cumple.
+type ProtoMatcher func(msg proto.Message) bool
+
+// ProtoMatcherE es como ProtoMatcher pero devuelve los errores de tipos
+// incompatibles o campos que no existen en el mensaje.
+type ProtoMatcherE func(msg proto.Message) (bool, error)
+
+func (fs Filters) ProtoMatcher(query string) (ProtoMatcher, error) {
+	matcher, err := fs.ProtoMatcherE(query)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	return func(msg proto.Message) bool {
+		result, err := matcher(msg)
+		return err == nil && result
+	}, nil
+}
+
+func (fs Filters) ProtoMatcherE(query string) (ProtoMatcherE, error) {
+	root, filters, err := fs.parseMatcherQuery(query)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	return func(msg proto.Message) (bool, error) {
+		if msg == nil {
+			return false, errors.Errorf("cannot match nil message")
+		}
+		m := proto.MessageReflect(msg)
+
+		return matchNode(root, filters, func(name string) (interface{}, error) {
+			return lookupProtoField(m, strings.Split(name, "."), name)
+		})
+	}, nil
+}
+
+func lookupProtoField(m protoreflect.Message, parts []string, name string) (interface{}, error) {
+	fields := m.Descriptor().Fields()
+	fd := fields.ByJSONName(parts[0])
+	if fd == nil {
+		fd = fields.ByName(protoreflect.Name(parts[0]))
+	}
+	if fd == nil {
+		return nil, errors.Errorf("field %q not found in message %s", name, m.Descriptor().FullName())
+	}
+	if fd.IsMap() {
+		return nil, errors.Errorf("cannot match map field %q", name)
+	}
+
+	if fd.IsList() {
+		list := m.Get(fd).List()
+		values := make(repeatedValue, 0, list.Len())
+		for i := 0; i < list.Len(); i++ {
+			value, err := protoFieldValue(fd, list.Get(i), parts[1:], name)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+
+			// Las listas dentro de mensajes repetidos se aplanan en una sola.
+			if nested, ok := value.(repeatedValue); ok {
+				values = append(values, nested...)
+			} else {
+				values = append(values, value)
+			}
+		}
+		return values, nil
+	}
+
+	// Los mensajes, los oneof y los campos opcionales que no estén asignados se
+	// comportan como si no existieran. El resto de campos tienen su valor por
+	// defecto.
+	if fd.HasPresence() && !m.Has(fd) {
+		return nil, nil
+	}
+
+	return protoFieldValue(fd, m.Get(fd), parts[1:], name)
+}
+
+func protoFieldValue(fd protoreflect.FieldDescriptor, value protoreflect.Value, rest []string, name string) (interface{}, error) {
+	if len(rest) > 0 {
+		if fd.Message() == nil {
+			return nil, errors.Errorf("cannot read field %q of non message field %s", name, fd.FullName())
+		}
+		return lookupProtoField(value.Message(), rest, name)
+	}
+
+	switch fd.Kind() {
+	case protoreflect.EnumKind:
+		if ev := fd.Enum().Values().ByNumber(value.Enum()); ev != nil {
+			return string(ev.Name()), nil
+		}
+		return int64(value.Enum()), nil
+
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return protoMessageValue(value.Message()), nil
+	}
+
+	return value.Interface(), nil
+}
+
+// protoMessageValue convierte los tipos conocidos de Google en sus valores Go
+// equivalentes para poder compararlos.
+func protoMessageValue(m protoreflect.Message) interface{} {
+	fields := m.Descriptor().Fields()
+	switch m.Descriptor().FullName() {
+	case "google.protobuf.Timestamp":
+		seconds := m.Get(fields.ByName("seconds")).Int()
+		nanos := m.Get(fields.ByName("nanos")).Int()
+		return time.Unix(seconds, nanos).UTC()
+
+	case "google.protobuf.DoubleValue", "google.protobuf.FloatValue",
+		"google.protobuf.Int64Value", "google.protobuf.UInt64Value",
+		"google.protobuf.Int32Value", "google.protobuf.UInt32Value",
+		"google.protobuf.BoolValue", "google.protobuf.StringValue", "google.protobuf.BytesValue":
+		return m.Get(fields.ByName("value")).Interface()
+	}
+
+	return m.Interface()
+}
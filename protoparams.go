@@ -0,0 +1,231 @@
+package expr
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	protov2 "google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/altipla-consulting/expr/exprpb"
+	"github.com/altipla-consulting/expr/parse"
+)
+
+// ProtoOption configura los filtros que genera ProtoFilters.
+type ProtoOption func(opts *protoOptions)
+
+type protoOptions struct {
+	include   []string
+	exclude   []string
+	overrides []*Filter
+	fieldOpts []protoFieldOptions
+}
+
+type protoFieldOptions struct {
+	name string
+	opts []ParamOption
+}
+
+// IncludeFields genera filtros solo para los campos indicados.
+func IncludeFields(names ...string) ProtoOption {
+	return func(opts *protoOptions) {
+		opts.include = append(opts.include, names...)
+	}
+}
+
+// ExcludeFields no genera filtros para los campos indicados.
+func ExcludeFields(names ...string) ProtoOption {
+	return func(opts *protoOptions) {
+		opts.exclude = append(opts.exclude, names...)
+	}
+}
+
+// OverrideParam usa el filtro indicado en lugar del que se generaría para el
+// campo con el mismo nombre.
+func OverrideParam(filter *Filter) ProtoOption {
+	return func(opts *protoOptions) {
+		opts.overrides = append(opts.overrides, filter)
+	}
+}
+
+// FieldOptions aplica opciones adicionales al filtro generado para un campo.
+// Si se indica varias veces para el mismo campo, aunque sea con su nombre JSON
+// y con el del proto, se aplican todas en orden.
+func FieldOptions(name string, paramOpts ...ParamOption) ProtoOption {
+	return func(opts *protoOptions) {
+		opts.fieldOpts = append(opts.fieldOpts, protoFieldOptions{name: name, opts: paramOpts})
+	}
+}
+
+// ProtoFilters genera los filtros a partir de los campos del mensaje usando su
+// nombre JSON. Los enteros se filtran con IntParam salvo los identificadores
+// (id o terminados en Id) que usan IDParam; las enumeraciones, booleanos,
+// strings, números decimales, Timestamp y wrappers usan su filtro equivalente.
+// El resto de campos, las listas y los mapas se ignoran.
+//
+// Si algún campo del mensaje tiene la opción (expr.filter) solo se generan
+// filtros para los campos que la tengan, configurados según la opción.
+//
+// Los nombres de las opciones pueden ser el nombre JSON o el del proto y tienen
+// que existir en el mensaje.
+func ProtoFilters(msg proto.Message, opts ...ProtoOption) Filters {
+	options := new(protoOptions)
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	md := proto.MessageReflect(msg).Descriptor()
+	include := protoFieldNumbers(md, options.include)
+	exclude := protoFieldNumbers(md, options.exclude)
+	overrides := make(map[protoreflect.FieldNumber]*Filter)
+	for _, f := range options.overrides {
+		overrides[mustProtoField(md, f.name).Number()] = f
+	}
+	fieldOpts := make(map[protoreflect.FieldNumber][]ParamOption)
+	for _, fo := range options.fieldOpts {
+		number := mustProtoField(md, fo.name).Number()
+		fieldOpts[number] = append(fieldOpts[number], fo.opts...)
+	}
+
+	fields := md.Fields()
+	annotations := make(map[protoreflect.FieldNumber]*exprpb.FilterOptions)
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		if protov2.HasExtension(fd.Options(), exprpb.E_Filter) {
+			annotations[fd.Number()] = protov2.GetExtension(fd.Options(), exprpb.E_Filter).(*exprpb.FilterOptions)
+		}
+	}
+
+	var filters Filters
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		if len(include) > 0 && !include[fd.Number()] {
+			continue
+		}
+		annotation, ok := annotations[fd.Number()]
+		if len(include) == 0 && len(annotations) > 0 && !ok {
+			continue
+		}
+		if exclude[fd.Number()] {
+			continue
+		}
+
+		if f, ok := overrides[fd.Number()]; ok {
+			filters = append(filters, f)
+			continue
+		}
+		paramOpts := append(annotationParamOptions(fd, annotation), fieldOpts[fd.Number()]...)
+		if f := protoFieldFilter(fd, paramOpts); f != nil {
+			filters = append(filters, f)
+		}
+	}
+	return filters
+}
+
+func protoFieldFilter(fd protoreflect.FieldDescriptor, opts []ParamOption) *Filter {
+	if fd.IsList() || fd.IsMap() {
+		return nil
+	}
+
+	name := fd.JSONName()
+	kind := fd.Kind()
+	if kind == protoreflect.MessageKind {
+		switch fd.Message().FullName() {
+		case "google.protobuf.Timestamp":
+			return TimestampParam(name, opts...)
+
+		case "google.protobuf.DoubleValue", "google.protobuf.FloatValue",
+			"google.protobuf.Int64Value", "google.protobuf.UInt64Value",
+			"google.protobuf.Int32Value", "google.protobuf.UInt32Value",
+			"google.protobuf.BoolValue", "google.protobuf.StringValue":
+			kind = fd.Message().Fields().ByName("value").Kind()
+
+		default:
+			return nil
+		}
+	}
+
+	switch kind {
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind,
+		protoreflect.Uint32Kind, protoreflect.Fixed32Kind,
+		protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		if name == "id" || strings.HasSuffix(name, "Id") {
+			return IDParam(name, opts...)
+		}
+		return IntParam(name, opts...)
+
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		return FloatParam(name, opts...)
+
+	case protoreflect.BoolKind:
+		return BoolParam(name, opts...)
+
+	case protoreflect.StringKind:
+		return StringParam(name, opts...)
+
+	case protoreflect.EnumKind:
+		values := make(map[string]int32)
+		enumValues := fd.Enum().Values()
+		for i := 0; i < enumValues.Len(); i++ {
+			values[string(enumValues.Get(i).Name())] = int32(enumValues.Get(i).Number())
+		}
+		return EnumParam(name, values, opts...)
+	}
+
+	return nil
+}
+
+func annotationParamOptions(fd protoreflect.FieldDescriptor, annotation *exprpb.FilterOptions) []ParamOption {
+	if annotation == nil {
+		return nil
+	}
+
+	var opts []ParamOption
+	if len(annotation.Operators) > 0 {
+		operators := make([]parse.Operator, len(annotation.Operators))
+		for i, op := range annotation.Operators {
+			operators[i] = parse.Operator(op)
+			if !operators[i].Valid() {
+				panic(fmt.Sprintf("invalid operator in filter options of field %s: %q", fd.FullName(), op))
+			}
+		}
+		opts = append(opts, Operators(operators...))
+	}
+	if annotation.Required {
+		opts = append(opts, Required())
+	}
+	for _, alias := range annotation.Aliases {
+		opts = append(opts, Alias(alias))
+	}
+	if annotation.Description != "" {
+		opts = append(opts, Description(annotation.Description))
+	}
+	if annotation.Deprecated != "" {
+		opts = append(opts, Deprecated(annotation.Deprecated))
+	}
+	if annotation.Column != "" {
+		opts = append(opts, Column(annotation.Column))
+	}
+	return opts
+}
+
+func protoFieldNumbers(md protoreflect.MessageDescriptor, names []string) map[protoreflect.FieldNumber]bool {
+	numbers := make(map[protoreflect.FieldNumber]bool)
+	for _, name := range names {
+		numbers[mustProtoField(md, name).Number()] = true
+	}
+	return numbers
+}
+
+func mustProtoField(md protoreflect.MessageDescriptor, name string) protoreflect.FieldDescriptor {
+	fd := md.Fields().ByJSONName(name)
+	if fd == nil {
+		fd = md.Fields().ByName(protoreflect.Name(name))
+	}
+	if fd == nil {
+		panic(fmt.Sprintf("unknown field %q in message %s", name, md.FullName()))
+	}
+	return fd
+}
@@ -0,0 +1,295 @@
+//go:build cgo
+// +build cgo
+
+package expr
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+
+	pb "github.com/altipla-consulting/expr/testdata/foo"
+)
+
+func openSQLite(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+
+	_, err = db.Exec(`
+		CREATE TABLE items (
+			id INTEGER PRIMARY KEY,
+			name TEXT,
+			status TEXT,
+			active INTEGER,
+			created_at TEXT,
+			rating REAL,
+			data TEXT
+		)
+	`)
+	require.NoError(t, err)
+
+	rows := []struct {
+		id        int64
+		name      string
+		status    string
+		active    bool
+		createdAt interface{}
+		rating    float64
+		data      string
+	}{
+		{1, "foo", "FOOENUM_FIRST", true, time.Date(2019, time.March, 2, 14, 15, 16, 0, time.UTC), 4.5, `{"address": {"city": "Madrid"}}`},
+		{2, "foo_bar", "FOOENUM_SECOND", false, time.Date(2020, time.January, 1, 0, 0, 0, 500, time.UTC), 3, `{"address": {"city": "Paris"}}`},
+		{3, "100% bar", "FOOENUM_FIRST", false, nil, 2.25, `{}`},
+	}
+	for _, row := range rows {
+		createdAt := row.createdAt
+		if t, ok := createdAt.(time.Time); ok {
+			createdAt = t.Format(SQLiteTimeFormat)
+		}
+		_, err := db.Exec(`INSERT INTO items VALUES (?, ?, ?, ?, ?, ?, ?)`, row.id, row.name, row.status, row.active, createdAt, row.rating, row.data)
+		require.NoError(t, err)
+	}
+
+	return db
+}
+
+func TestSQLite(t *testing.T) {
+	db := openSQLite(t)
+	defer db.Close()
+
+	filters := Filters{
+		IDParam("id"),
+		StringParam("name"),
+		EnumParam("status", pb.FooEnum_value),
+		BoolParam("active"),
+		TimestampParam("createdAt"),
+		FloatParam("rating"),
+		StringParam("city", JSONColumn("data", "address", "city")),
+	}
+
+	tests := []struct {
+		query    string
+		expected []int64
+	}{
+		{``, []int64{1, 2, 3}},
+		{`id=2`, []int64{2}},
+		{`-id=2`, []int64{1, 3}},
+		{`id IN (1, 3)`, []int64{1, 3}},
+		{`status=FOOENUM_FIRST`, []int64{1, 3}},
+		{`active=true`, []int64{1}},
+		{`active=false`, []int64{2, 3}},
+		{`name:foo`, []int64{1, 2}},
+		{`name:"o_b"`, []int64{2}},
+		{`name:"%"`, []int64{3}},
+		{`NOT name:foo`, []int64{3}},
+		{`createdAt:*`, []int64{1, 2}},
+		{`-createdAt:*`, []int64{3}},
+		{`createdAt>"2019-03-02T14:15:16Z"`, []int64{2}},
+		{`createdAt>="2019-03-02T14:15:16Z"`, []int64{1, 2}},
+		{`createdAt<"2020-01-01"`, []int64{1}},
+		{`createdAt>"2020-01-01"`, []int64{2}},
+		{`rating>=3`, []int64{1, 2}},
+		{`rating<2.5 OR active=true`, []int64{1, 3}},
+		{`city=Madrid`, []int64{1}},
+		{`(status=FOOENUM_SECOND OR city=Madrid) NOT active=false`, []int64{1}},
+	}
+	for i, test := range tests {
+		where, vals, err := filters.ToSQL(test.query, WithDialect(SQLite))
+		require.NoError(t, err, "test %v: [%v]", i, test.query)
+
+		q := `SELECT id FROM items`
+		if where != "" {
+			q += " WHERE " + where
+		}
+		q += " ORDER BY id"
+
+		rows, err := db.Query(q, vals...)
+		require.NoError(t, err, "test %v: [%v]: %v", i, test.query, q)
+
+		var ids []int64
+		for rows.Next() {
+			var id int64
+			require.NoError(t, rows.Scan(&id))
+			ids = append(ids, id)
+		}
+		require.NoError(t, rows.Err())
+		rows.Close()
+
+		require.Equal(t, test.expected, ids, "test %v: [%v]: %v", i, test.query, q)
+	}
+}
+
+func TestSQLiteGeneratedSQL(t *testing.T) {
+	filters := Filters{
+		StringParam("name", Column("items.name")),
+		BoolParam("active"),
+	}
+
+	where, vals, err := filters.ToSQL(`name:"50%" -active=true`, WithDialect(SQLite))
+	require.NoError(t, err)
+	require.Equal(t, `("items"."name" LIKE ? ESCAPE '\') AND ("active" IS NOT ?)`, where)
+	require.Equal(t, []interface{}{`%50\%%`, int64(1)}, vals)
+}
+
+func TestSQLiteKeysetPagination(t *testing.T) {
+	db := openSQLite(t)
+	defer db.Close()
+
+	_, err := db.Exec(`INSERT INTO items (id, name, status, active, rating) VALUES (4, 'qux', 'FOOENUM_SECOND', 1, 3)`)
+	require.NoError(t, err)
+
+	filters := Filters{
+		BoolParam("active"),
+	}
+	orders := OrderFields{
+		OrderParam("rating"),
+		OrderParam("id"),
+	}
+	tokens := NewPageTokens(testPageKey)
+
+	tests := []struct {
+		orderBy string
+		dialect Dialect
+	}{
+		{"rating desc, id desc", SQLite},
+		{"rating desc, id desc", expandedKeysetDialect{SQLite}},
+		{"rating desc, id", SQLite},
+	}
+	for _, test := range tests {
+		ordering, err := orders.Parse(test.orderBy)
+		require.NoError(t, err)
+
+		var ids []int64
+		var pageToken string
+		for {
+			opts := []SQLOption{WithDialect(test.dialect)}
+			if pageToken != "" {
+				cursor, err := tokens.Decode(pageToken, "", ordering)
+				require.NoError(t, err)
+				opts = append(opts, WithKeyset(ordering, cursor))
+			}
+			where, vals, err := filters.ToSQL("", opts...)
+			require.NoError(t, err)
+
+			q := `SELECT id, rating FROM items`
+			if where != "" {
+				q += " WHERE " + where
+			}
+			orderBy, err := ordering.ToSQL(WithDialect(SQLite))
+			require.NoError(t, err)
+			q += " ORDER BY " + orderBy + " LIMIT 1"
+
+			var id int64
+			var rating float64
+			err = db.QueryRow(q, vals...).Scan(&id, &rating)
+			if err == sql.ErrNoRows {
+				break
+			}
+			require.NoError(t, err, q)
+			ids = append(ids, id)
+
+			pageToken, err = tokens.Encode("", ordering, []interface{}{rating, id})
+			require.NoError(t, err)
+		}
+
+		if ordering[1].Desc {
+			require.Equal(t, []int64{1, 4, 2, 3}, ids, test.orderBy)
+		} else {
+			require.Equal(t, []int64{1, 2, 4, 3}, ids, test.orderBy)
+		}
+	}
+}
+
+func TestSQLiteNullsMatchMatcher(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec(`
+		CREATE TABLE items (
+			id INTEGER PRIMARY KEY,
+			name TEXT,
+			active INTEGER,
+			rating REAL,
+			created_at TEXT
+		)
+	`)
+	require.NoError(t, err)
+
+	items := []map[string]interface{}{
+		{"id": int64(1), "name": "foo", "active": true, "rating": 4.5, "createdAt": time.Date(2019, time.March, 2, 14, 15, 16, 0, time.UTC)},
+		{"id": int64(2), "name": "bar", "active": false, "rating": 3.0},
+		{"id": int64(3)},
+	}
+	for _, item := range items {
+		var createdAt interface{}
+		if t, ok := item["createdAt"].(time.Time); ok {
+			createdAt = t.Format(SQLiteTimeFormat)
+		}
+		_, err := db.Exec(`INSERT INTO items VALUES (?, ?, ?, ?, ?)`, item["id"], item["name"], item["active"], item["rating"], createdAt)
+		require.NoError(t, err)
+	}
+
+	filters := Filters{
+		IDParam("id"),
+		StringParam("name"),
+		BoolParam("active"),
+		FloatParam("rating"),
+		TimestampParam("createdAt"),
+	}
+
+	queries := []string{
+		`name="foo"`,
+		`name!="foo"`,
+		`-name="foo"`,
+		`-name!="foo"`,
+		`name:fo`,
+		`-name:fo`,
+		`name IN (foo, bar)`,
+		`-name IN (foo)`,
+		`rating>3`,
+		`-rating>3`,
+		`rating<=3`,
+		`-rating<=3`,
+		`active=true`,
+		`active!=true`,
+		`-active=false`,
+		`createdAt:*`,
+		`-createdAt:*`,
+		`-createdAt>"2019-01-01"`,
+		`NOT (name="foo" rating>3)`,
+		`NOT (name="foo" OR rating<4)`,
+		`NOT (-name="foo")`,
+		`NOT (NOT (rating>3) OR id=2)`,
+		`name="bar" OR -rating>=4`,
+	}
+	for _, query := range queries {
+		where, vals, err := filters.ToSQL(query, WithDialect(SQLite))
+		require.NoError(t, err, query)
+
+		rows, err := db.Query(`SELECT id FROM items WHERE `+where+` ORDER BY id`, vals...)
+		require.NoError(t, err, "%v: %v", query, where)
+		var got []int64
+		for rows.Next() {
+			var id int64
+			require.NoError(t, rows.Scan(&id))
+			got = append(got, id)
+		}
+		require.NoError(t, rows.Err())
+		rows.Close()
+
+		matcher, err := filters.Matcher(query)
+		require.NoError(t, err, query)
+		var expected []int64
+		for _, item := range items {
+			if matcher(item) {
+				expected = append(expected, item["id"].(int64))
+			}
+		}
+
+		require.Equal(t, expected, got, "%v: %v", query, where)
+	}
+}
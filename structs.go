@@ -0,0 +1,177 @@
+package expr
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+	"unicode"
+
+	"libs.altipla.consulting/errors"
+)
+
+// StructMatcher comprueba la query contra un struct de Go (o un puntero a él).
+// Los campos se buscan por su tag `expr:"name"` o, si no lo tienen, por su
+// nombre en lowerCamelCase (ID -> id, CreatedAt -> createdAt). Se puede
+// acceder a structs anidados con nombres separados por puntos.
+type StructMatcher func(value interface{}) bool
+
+// StructMatcherE es como StructMatcher pero devuelve los errores de tipos
+// incompatibles o campos que no existen en el struct.
+type StructMatcherE func(value interface{}) (bool, error)
+
+func (fs Filters) StructMatcher(query string) (StructMatcher, error) {
+	matcher, err := fs.StructMatcherE(query)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	return func(value interface{}) bool {
+		result, err := matcher(value)
+		return err == nil && result
+	}, nil
+}
+
+func (fs Filters) StructMatcherE(query string) (StructMatcherE, error) {
+	root, filters, err := fs.parseMatcherQuery(query)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	return func(value interface{}) (bool, error) {
+		rv := reflect.ValueOf(value)
+		for rv.Kind() == reflect.Ptr {
+			if rv.IsNil() {
+				return false, errors.Errorf("cannot match nil value")
+			}
+			rv = rv.Elem()
+		}
+		if rv.Kind() != reflect.Struct {
+			return false, errors.Errorf("cannot match value of type %T, expected a struct", value)
+		}
+
+		return matchNode(root, filters, func(name string) (interface{}, error) {
+			return lookupStructField(rv, name)
+		})
+	}, nil
+}
+
+type structFields map[string][]int
+
+var structFieldsCache sync.Map
+
+func cachedStructFields(t reflect.Type) structFields {
+	if fields, ok := structFieldsCache.Load(t); ok {
+		return fields.(structFields)
+	}
+
+	fields := make(structFields)
+	collectStructFields(fields, t, nil, map[reflect.Type]bool{t: true})
+	actual, _ := structFieldsCache.LoadOrStore(t, fields)
+	return actual.(structFields)
+}
+
+// collectStructFields recoge los campos del struct y de sus structs embebidos.
+// Los tipos que ya se están recorriendo se ignoran para no entrar en un bucle
+// infinito con structs que se embeben a sí mismos.
+func collectStructFields(fields structFields, t reflect.Type, parent []int, visited map[reflect.Type]bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		index := append(append([]int{}, parent...), i)
+
+		tag := field.Tag.Get("expr")
+		if tag == "-" {
+			continue
+		}
+
+		// Los structs embebidos sin tag aportan sus campos al nivel actual.
+		if field.Anonymous && tag == "" {
+			ft := field.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				if !visited[ft] {
+					visited[ft] = true
+					collectStructFields(fields, ft, index, visited)
+					delete(visited, ft)
+				}
+				continue
+			}
+		}
+
+		if field.PkgPath != "" {
+			continue
+		}
+
+		name := tag
+		if name == "" {
+			name = lowerCamelName(field.Name)
+		}
+
+		// Los campos directos tienen prioridad sobre los de structs embebidos.
+		if prev, ok := fields[name]; ok && len(prev) <= len(index) {
+			continue
+		}
+		fields[name] = index
+	}
+}
+
+func lookupStructField(v reflect.Value, name string) (interface{}, error) {
+	for _, part := range strings.Split(name, ".") {
+		for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+			if v.IsNil() {
+				return nil, nil
+			}
+			v = v.Elem()
+		}
+		if v.Kind() != reflect.Struct {
+			return nil, errors.Errorf("cannot read field %q of non struct type %s", part, v.Type())
+		}
+
+		index, ok := cachedStructFields(v.Type())[part]
+		if !ok {
+			return nil, errors.Errorf("field %q not found in struct %s", name, v.Type())
+		}
+
+		// Un struct embebido nulo se comporta como si el campo no existiera.
+		for i, idx := range index {
+			if i > 0 {
+				if v.Kind() == reflect.Ptr {
+					if v.IsNil() {
+						return nil, nil
+					}
+					v = v.Elem()
+				}
+			}
+			v = v.Field(idx)
+		}
+	}
+
+	return v.Interface(), nil
+}
+
+// lowerCamelName convierte el nombre de un campo de Go a lowerCamelCase
+// respetando los acrónimos: ID -> id, UserID -> userID, HTTPStatus -> httpStatus.
+func lowerCamelName(name string) string {
+	runes := []rune(name)
+	upper := 0
+	for upper < len(runes) && unicode.IsUpper(runes[upper]) {
+		upper++
+	}
+
+	switch {
+	case upper == 0:
+		return name
+	case upper == 1 || upper == len(runes):
+	default:
+		// El último carácter en mayúscula empieza ya la siguiente palabra.
+		if unicode.IsLower(runes[upper]) {
+			upper--
+		}
+	}
+
+	for i := 0; i < upper; i++ {
+		runes[i] = unicode.ToLower(runes[i])
+	}
+	return string(runes)
+}
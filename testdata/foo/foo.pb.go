@@ -1,24 +1,27 @@
 // Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.27.1
+// 	protoc        (unknown)
 // source: testdata/foo/foo.proto
 
 package expr_testdata_foo
 
 import (
-	fmt "fmt"
-	proto "github.com/golang/protobuf/proto"
-	math "math"
+	_ "github.com/altipla-consulting/expr/exprpb"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	wrapperspb "google.golang.org/protobuf/types/known/wrapperspb"
+	reflect "reflect"
+	sync "sync"
 )
 
-// Reference imports to suppress errors if they are not otherwise used.
-var _ = proto.Marshal
-var _ = fmt.Errorf
-var _ = math.Inf
-
-// This is a compile-time assertion to ensure that this generated file
-// is compatible with the proto package it is being compiled against.
-// A compilation error at this line likely means your copy of the
-// proto package needs to be updated.
-const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
 
 type FooEnum int32
 
@@ -28,42 +31,495 @@ const (
 	FooEnum_FOOENUM_SECOND  FooEnum = 2
 )
 
-var FooEnum_name = map[int32]string{
-	0: "FOOENUM_UNKNOWN",
-	1: "FOOENUM_FIRST",
-	2: "FOOENUM_SECOND",
-}
+// Enum value maps for FooEnum.
+var (
+	FooEnum_name = map[int32]string{
+		0: "FOOENUM_UNKNOWN",
+		1: "FOOENUM_FIRST",
+		2: "FOOENUM_SECOND",
+	}
+	FooEnum_value = map[string]int32{
+		"FOOENUM_UNKNOWN": 0,
+		"FOOENUM_FIRST":   1,
+		"FOOENUM_SECOND":  2,
+	}
+)
 
-var FooEnum_value = map[string]int32{
-	"FOOENUM_UNKNOWN": 0,
-	"FOOENUM_FIRST":   1,
-	"FOOENUM_SECOND":  2,
+func (x FooEnum) Enum() *FooEnum {
+	p := new(FooEnum)
+	*p = x
+	return p
 }
 
 func (x FooEnum) String() string {
-	return proto.EnumName(FooEnum_name, int32(x))
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (FooEnum) Descriptor() protoreflect.EnumDescriptor {
+	return file_testdata_foo_foo_proto_enumTypes[0].Descriptor()
 }
 
+func (FooEnum) Type() protoreflect.EnumType {
+	return &file_testdata_foo_foo_proto_enumTypes[0]
+}
+
+func (x FooEnum) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use FooEnum.Descriptor instead.
 func (FooEnum) EnumDescriptor() ([]byte, []int) {
-	return fileDescriptor_2c0a185f62bb6738, []int{0}
+	return file_testdata_foo_foo_proto_rawDescGZIP(), []int{0}
+}
+
+type Foo struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id          int64                   `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	DisplayName string                  `protobuf:"bytes,2,opt,name=display_name,json=displayName,proto3" json:"display_name,omitempty"`
+	Status      FooEnum                 `protobuf:"varint,3,opt,name=status,proto3,enum=expr.testdata.foo.FooEnum" json:"status,omitempty"`
+	Enabled     bool                    `protobuf:"varint,4,opt,name=enabled,proto3" json:"enabled,omitempty"`
+	Rating      float64                 `protobuf:"fixed64,5,opt,name=rating,proto3" json:"rating,omitempty"`
+	CreateTime  *timestamppb.Timestamp  `protobuf:"bytes,6,opt,name=create_time,json=createTime,proto3" json:"create_time,omitempty"`
+	Nickname    *wrapperspb.StringValue `protobuf:"bytes,7,opt,name=nickname,proto3" json:"nickname,omitempty"`
+	Tags        []string                `protobuf:"bytes,8,rep,name=tags,proto3" json:"tags,omitempty"`
+	Bar         *Bar                    `protobuf:"bytes,9,opt,name=bar,proto3" json:"bar,omitempty"`
+	Bars        []*Bar                  `protobuf:"bytes,10,rep,name=bars,proto3" json:"bars,omitempty"`
+	// Types that are assignable to Source:
+	//	*Foo_Url
+	//	*Foo_Code
+	Source isFoo_Source `protobuf_oneof:"source"`
+}
+
+func (x *Foo) Reset() {
+	*x = Foo{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_testdata_foo_foo_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Foo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Foo) ProtoMessage() {}
+
+func (x *Foo) ProtoReflect() protoreflect.Message {
+	mi := &file_testdata_foo_foo_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Foo.ProtoReflect.Descriptor instead.
+func (*Foo) Descriptor() ([]byte, []int) {
+	return file_testdata_foo_foo_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Foo) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
 }
 
-func init() {
-	proto.RegisterEnum("expr.testdata.foo.FooEnum", FooEnum_name, FooEnum_value)
+func (x *Foo) GetDisplayName() string {
+	if x != nil {
+		return x.DisplayName
+	}
+	return ""
 }
 
-func init() {
-	proto.RegisterFile("testdata/foo/foo.proto", fileDescriptor_2c0a185f62bb6738)
+func (x *Foo) GetStatus() FooEnum {
+	if x != nil {
+		return x.Status
+	}
+	return FooEnum_FOOENUM_UNKNOWN
+}
+
+func (x *Foo) GetEnabled() bool {
+	if x != nil {
+		return x.Enabled
+	}
+	return false
+}
+
+func (x *Foo) GetRating() float64 {
+	if x != nil {
+		return x.Rating
+	}
+	return 0
+}
+
+func (x *Foo) GetCreateTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreateTime
+	}
+	return nil
+}
+
+func (x *Foo) GetNickname() *wrapperspb.StringValue {
+	if x != nil {
+		return x.Nickname
+	}
+	return nil
+}
+
+func (x *Foo) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *Foo) GetBar() *Bar {
+	if x != nil {
+		return x.Bar
+	}
+	return nil
+}
+
+func (x *Foo) GetBars() []*Bar {
+	if x != nil {
+		return x.Bars
+	}
+	return nil
+}
+
+func (m *Foo) GetSource() isFoo_Source {
+	if m != nil {
+		return m.Source
+	}
+	return nil
+}
+
+func (x *Foo) GetUrl() string {
+	if x, ok := x.GetSource().(*Foo_Url); ok {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *Foo) GetCode() int64 {
+	if x, ok := x.GetSource().(*Foo_Code); ok {
+		return x.Code
+	}
+	return 0
+}
+
+type isFoo_Source interface {
+	isFoo_Source()
+}
+
+type Foo_Url struct {
+	Url string `protobuf:"bytes,11,opt,name=url,proto3,oneof"`
+}
+
+type Foo_Code struct {
+	Code int64 `protobuf:"varint,12,opt,name=code,proto3,oneof"`
+}
+
+func (*Foo_Url) isFoo_Source() {}
+
+func (*Foo_Code) isFoo_Source() {}
+
+type Bar struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Name  string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Count int32  `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+}
+
+func (x *Bar) Reset() {
+	*x = Bar{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_testdata_foo_foo_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Bar) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Bar) ProtoMessage() {}
+
+func (x *Bar) ProtoReflect() protoreflect.Message {
+	mi := &file_testdata_foo_foo_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Bar.ProtoReflect.Descriptor instead.
+func (*Bar) Descriptor() ([]byte, []int) {
+	return file_testdata_foo_foo_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Bar) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Bar) GetCount() int32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+type Annotated struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id       int64  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name     string `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Count    int32  `protobuf:"varint,3,opt,name=count,proto3" json:"count,omitempty"`
+	Internal string `protobuf:"bytes,4,opt,name=internal,proto3" json:"internal,omitempty"`
+}
+
+func (x *Annotated) Reset() {
+	*x = Annotated{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_testdata_foo_foo_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Annotated) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Annotated) ProtoMessage() {}
+
+func (x *Annotated) ProtoReflect() protoreflect.Message {
+	mi := &file_testdata_foo_foo_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Annotated.ProtoReflect.Descriptor instead.
+func (*Annotated) Descriptor() ([]byte, []int) {
+	return file_testdata_foo_foo_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Annotated) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Annotated) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Annotated) GetCount() int32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *Annotated) GetInternal() string {
+	if x != nil {
+		return x.Internal
+	}
+	return ""
+}
+
+var File_testdata_foo_foo_proto protoreflect.FileDescriptor
+
+var file_testdata_foo_foo_proto_rawDesc = []byte{
+	0x0a, 0x16, 0x74, 0x65, 0x73, 0x74, 0x64, 0x61, 0x74, 0x61, 0x2f, 0x66, 0x6f, 0x6f, 0x2f, 0x66,
+	0x6f, 0x6f, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x11, 0x65, 0x78, 0x70, 0x72, 0x2e, 0x74,
+	0x65, 0x73, 0x74, 0x64, 0x61, 0x74, 0x61, 0x2e, 0x66, 0x6f, 0x6f, 0x1a, 0x1f, 0x67, 0x6f, 0x6f,
+	0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x74, 0x69, 0x6d,
+	0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x1e, 0x67, 0x6f,
+	0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x77, 0x72,
+	0x61, 0x70, 0x70, 0x65, 0x72, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x11, 0x65, 0x78,
+	0x70, 0x72, 0x70, 0x62, 0x2f, 0x65, 0x78, 0x70, 0x72, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22,
+	0xb3, 0x03, 0x0a, 0x03, 0x46, 0x6f, 0x6f, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x02, 0x69, 0x64, 0x12, 0x21, 0x0a, 0x0c, 0x64, 0x69, 0x73, 0x70, 0x6c,
+	0x61, 0x79, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x64,
+	0x69, 0x73, 0x70, 0x6c, 0x61, 0x79, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x32, 0x0a, 0x06, 0x73, 0x74,
+	0x61, 0x74, 0x75, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x1a, 0x2e, 0x65, 0x78, 0x70,
+	0x72, 0x2e, 0x74, 0x65, 0x73, 0x74, 0x64, 0x61, 0x74, 0x61, 0x2e, 0x66, 0x6f, 0x6f, 0x2e, 0x46,
+	0x6f, 0x6f, 0x45, 0x6e, 0x75, 0x6d, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x18,
+	0x0a, 0x07, 0x65, 0x6e, 0x61, 0x62, 0x6c, 0x65, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x08, 0x52,
+	0x07, 0x65, 0x6e, 0x61, 0x62, 0x6c, 0x65, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x72, 0x61, 0x74, 0x69,
+	0x6e, 0x67, 0x18, 0x05, 0x20, 0x01, 0x28, 0x01, 0x52, 0x06, 0x72, 0x61, 0x74, 0x69, 0x6e, 0x67,
+	0x12, 0x3b, 0x0a, 0x0b, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x18,
+	0x06, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d,
+	0x70, 0x52, 0x0a, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x54, 0x69, 0x6d, 0x65, 0x12, 0x38, 0x0a,
+	0x08, 0x6e, 0x69, 0x63, 0x6b, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x1c, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75,
+	0x66, 0x2e, 0x53, 0x74, 0x72, 0x69, 0x6e, 0x67, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x52, 0x08, 0x6e,
+	0x69, 0x63, 0x6b, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x61, 0x67, 0x73, 0x18,
+	0x08, 0x20, 0x03, 0x28, 0x09, 0x52, 0x04, 0x74, 0x61, 0x67, 0x73, 0x12, 0x28, 0x0a, 0x03, 0x62,
+	0x61, 0x72, 0x18, 0x09, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x65, 0x78, 0x70, 0x72, 0x2e,
+	0x74, 0x65, 0x73, 0x74, 0x64, 0x61, 0x74, 0x61, 0x2e, 0x66, 0x6f, 0x6f, 0x2e, 0x42, 0x61, 0x72,
+	0x52, 0x03, 0x62, 0x61, 0x72, 0x12, 0x2a, 0x0a, 0x04, 0x62, 0x61, 0x72, 0x73, 0x18, 0x0a, 0x20,
+	0x03, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x65, 0x78, 0x70, 0x72, 0x2e, 0x74, 0x65, 0x73, 0x74, 0x64,
+	0x61, 0x74, 0x61, 0x2e, 0x66, 0x6f, 0x6f, 0x2e, 0x42, 0x61, 0x72, 0x52, 0x04, 0x62, 0x61, 0x72,
+	0x73, 0x12, 0x12, 0x0a, 0x03, 0x75, 0x72, 0x6c, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x09, 0x48, 0x00,
+	0x52, 0x03, 0x75, 0x72, 0x6c, 0x12, 0x14, 0x0a, 0x04, 0x63, 0x6f, 0x64, 0x65, 0x18, 0x0c, 0x20,
+	0x01, 0x28, 0x03, 0x48, 0x00, 0x52, 0x04, 0x63, 0x6f, 0x64, 0x65, 0x42, 0x08, 0x0a, 0x06, 0x73,
+	0x6f, 0x75, 0x72, 0x63, 0x65, 0x22, 0x2f, 0x0a, 0x03, 0x42, 0x61, 0x72, 0x12, 0x12, 0x0a, 0x04,
+	0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65,
+	0x12, 0x14, 0x0a, 0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52,
+	0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x22, 0x9d, 0x01, 0x0a, 0x09, 0x41, 0x6e, 0x6e, 0x6f, 0x74,
+	0x61, 0x74, 0x65, 0x64, 0x12, 0x16, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03,
+	0x42, 0x06, 0xf2, 0x81, 0x19, 0x02, 0x10, 0x01, 0x52, 0x02, 0x69, 0x64, 0x12, 0x2d, 0x0a, 0x04,
+	0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x42, 0x19, 0xf2, 0x81, 0x19, 0x15,
+	0x0a, 0x01, 0x3d, 0x0a, 0x01, 0x3a, 0x1a, 0x05, 0x74, 0x69, 0x74, 0x6c, 0x65, 0x22, 0x06, 0x4e,
+	0x6f, 0x6d, 0x62, 0x72, 0x65, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x2d, 0x0a, 0x05, 0x63,
+	0x6f, 0x75, 0x6e, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x42, 0x17, 0xf2, 0x81, 0x19, 0x13,
+	0x0a, 0x01, 0x3d, 0x0a, 0x01, 0x3e, 0x32, 0x0b, 0x73, 0x74, 0x61, 0x74, 0x73, 0x2e, 0x63, 0x6f,
+	0x75, 0x6e, 0x74, 0x52, 0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x1a, 0x0a, 0x08, 0x69, 0x6e,
+	0x74, 0x65, 0x72, 0x6e, 0x61, 0x6c, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x69, 0x6e,
+	0x74, 0x65, 0x72, 0x6e, 0x61, 0x6c, 0x2a, 0x45, 0x0a, 0x07, 0x46, 0x6f, 0x6f, 0x45, 0x6e, 0x75,
+	0x6d, 0x12, 0x13, 0x0a, 0x0f, 0x46, 0x4f, 0x4f, 0x45, 0x4e, 0x55, 0x4d, 0x5f, 0x55, 0x4e, 0x4b,
+	0x4e, 0x4f, 0x57, 0x4e, 0x10, 0x00, 0x12, 0x11, 0x0a, 0x0d, 0x46, 0x4f, 0x4f, 0x45, 0x4e, 0x55,
+	0x4d, 0x5f, 0x46, 0x49, 0x52, 0x53, 0x54, 0x10, 0x01, 0x12, 0x12, 0x0a, 0x0e, 0x46, 0x4f, 0x4f,
+	0x45, 0x4e, 0x55, 0x4d, 0x5f, 0x53, 0x45, 0x43, 0x4f, 0x4e, 0x44, 0x10, 0x02, 0x42, 0x43, 0x5a,
+	0x41, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x61, 0x6c, 0x74, 0x69,
+	0x70, 0x6c, 0x61, 0x2d, 0x63, 0x6f, 0x6e, 0x73, 0x75, 0x6c, 0x74, 0x69, 0x6e, 0x67, 0x2f, 0x65,
+	0x78, 0x70, 0x72, 0x2f, 0x74, 0x65, 0x73, 0x74, 0x64, 0x61, 0x74, 0x61, 0x2f, 0x66, 0x6f, 0x6f,
+	0x3b, 0x65, 0x78, 0x70, 0x72, 0x5f, 0x74, 0x65, 0x73, 0x74, 0x64, 0x61, 0x74, 0x61, 0x5f, 0x66,
+	0x6f, 0x6f, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_testdata_foo_foo_proto_rawDescOnce sync.Once
+	file_testdata_foo_foo_proto_rawDescData = file_testdata_foo_foo_proto_rawDesc
+)
+
+func file_testdata_foo_foo_proto_rawDescGZIP() []byte {
+	file_testdata_foo_foo_proto_rawDescOnce.Do(func() {
+		file_testdata_foo_foo_proto_rawDescData = protoimpl.X.CompressGZIP(file_testdata_foo_foo_proto_rawDescData)
+	})
+	return file_testdata_foo_foo_proto_rawDescData
+}
+
+var file_testdata_foo_foo_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_testdata_foo_foo_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_testdata_foo_foo_proto_goTypes = []interface{}{
+	(FooEnum)(0),                   // 0: expr.testdata.foo.FooEnum
+	(*Foo)(nil),                    // 1: expr.testdata.foo.Foo
+	(*Bar)(nil),                    // 2: expr.testdata.foo.Bar
+	(*Annotated)(nil),              // 3: expr.testdata.foo.Annotated
+	(*timestamppb.Timestamp)(nil),  // 4: google.protobuf.Timestamp
+	(*wrapperspb.StringValue)(nil), // 5: google.protobuf.StringValue
+}
+var file_testdata_foo_foo_proto_depIdxs = []int32{
+	0, // 0: expr.testdata.foo.Foo.status:type_name -> expr.testdata.foo.FooEnum
+	4, // 1: expr.testdata.foo.Foo.create_time:type_name -> google.protobuf.Timestamp
+	5, // 2: expr.testdata.foo.Foo.nickname:type_name -> google.protobuf.StringValue
+	2, // 3: expr.testdata.foo.Foo.bar:type_name -> expr.testdata.foo.Bar
+	2, // 4: expr.testdata.foo.Foo.bars:type_name -> expr.testdata.foo.Bar
+	5, // [5:5] is the sub-list for method output_type
+	5, // [5:5] is the sub-list for method input_type
+	5, // [5:5] is the sub-list for extension type_name
+	5, // [5:5] is the sub-list for extension extendee
+	0, // [0:5] is the sub-list for field type_name
 }
 
-var fileDescriptor_2c0a185f62bb6738 = []byte{
-	// 122 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xe2, 0x12, 0x2b, 0x49, 0x2d, 0x2e,
-	0x49, 0x49, 0x2c, 0x49, 0xd4, 0x4f, 0xcb, 0xcf, 0x07, 0x61, 0xbd, 0x82, 0xa2, 0xfc, 0x92, 0x7c,
-	0x21, 0xc1, 0xd4, 0x8a, 0x82, 0x22, 0x3d, 0x98, 0xa4, 0x5e, 0x5a, 0x7e, 0xbe, 0x96, 0x2b, 0x17,
-	0xbb, 0x5b, 0x7e, 0xbe, 0x6b, 0x5e, 0x69, 0xae, 0x90, 0x30, 0x17, 0xbf, 0x9b, 0xbf, 0xbf, 0xab,
-	0x5f, 0xa8, 0x6f, 0x7c, 0xa8, 0x9f, 0xb7, 0x9f, 0x7f, 0xb8, 0x9f, 0x00, 0x83, 0x90, 0x20, 0x17,
-	0x2f, 0x4c, 0xd0, 0xcd, 0x33, 0x28, 0x38, 0x44, 0x80, 0x51, 0x48, 0x88, 0x8b, 0x0f, 0x26, 0x14,
-	0xec, 0xea, 0xec, 0xef, 0xe7, 0x22, 0xc0, 0x94, 0xc4, 0x06, 0xb6, 0xc0, 0x18, 0x10, 0x00, 0x00,
-	0xff, 0xff, 0x95, 0x0d, 0x08, 0x0e, 0x7a, 0x00, 0x00, 0x00,
+func init() { file_testdata_foo_foo_proto_init() }
+func file_testdata_foo_foo_proto_init() {
+	if File_testdata_foo_foo_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_testdata_foo_foo_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Foo); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_testdata_foo_foo_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Bar); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_testdata_foo_foo_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Annotated); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	file_testdata_foo_foo_proto_msgTypes[0].OneofWrappers = []interface{}{
+		(*Foo_Url)(nil),
+		(*Foo_Code)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_testdata_foo_foo_proto_rawDesc,
+			NumEnums:      1,
+			NumMessages:   3,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_testdata_foo_foo_proto_goTypes,
+		DependencyIndexes: file_testdata_foo_foo_proto_depIdxs,
+		EnumInfos:         file_testdata_foo_foo_proto_enumTypes,
+		MessageInfos:      file_testdata_foo_foo_proto_msgTypes,
+	}.Build()
+	File_testdata_foo_foo_proto = out.File
+	file_testdata_foo_foo_proto_rawDesc = nil
+	file_testdata_foo_foo_proto_goTypes = nil
+	file_testdata_foo_foo_proto_depIdxs = nil
 }